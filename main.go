@@ -2,8 +2,13 @@ package main
 
 import (
     "fmt"
+    "strconv"
+    "strings"
+    "sync"
     "database/sql"
     "flag"
+    "hash"
+    "hash/fnv"
     "net/http"
     "time"
     "io/ioutil"
@@ -20,15 +25,192 @@ const (
   exporter  = "exporter"
 )
 
+// Defaults for the optional settings of a connection.
+const (
+  defaultBlockersTop        = 10
+  defaultSessionGroupsLimit = 100
+  defaultTopSQLLimit        = 200
+  maxTopSQLTextLength       = 1000
+)
+
+// topSQLColumns maps the order_by values of topsql to the v$sqlstats columns.
+var topSQLColumns = map[string]string{
+  "elapsed_time":   "sum(elapsed_time)",
+  "cpu_time":       "sum(cpu_time)",
+  "executions":     "sum(executions)",
+  "buffer_gets":    "sum(buffer_gets)",
+  "disk_reads":     "sum(disk_reads)",
+  "rows_processed": "sum(rows_processed)",
+}
+
+// defaultSysstat are the v$sysstat statistics exported when no sysstat are set on the connection.
+var defaultSysstat = []string{
+  "parse count (total)",
+  "parse count (hard)",
+  "execute count",
+  "user commits",
+  "user rollbacks",
+  "user calls",
+  "session logical reads",
+  "physical reads",
+  "physical writes",
+  "db block changes",
+  "redo size",
+  "bytes sent via SQL*Net to client",
+  "bytes received via SQL*Net from client",
+}
+
+// defaultSysmetric are the v$sysmetric metrics exported when no sysmetric are set on the connection.
+var defaultSysmetric = []string{
+  "Host CPU Utilization (%)",
+  "Average Active Sessions",
+  "Physical Read Total IO Requests Per Sec",
+  "Physical Read Total Bytes Per Sec",
+  "Physical Write Total IO Requests Per Sec",
+  "Physical Write Total Bytes Per Sec",
+  "Buffer Cache Hit Ratio",
+  "Library Cache Hit Ratio",
+}
+
+// sysmetricUnits are the units of the v$sysmetric metrics (v$metricname), the metrics without
+// a known unit are named by their metric_name only.
+var sysmetricUnits = map[string]string{
+  "Host CPU Utilization (%)":                 "% Busy/(Idle+Busy)",
+  "Average Active Sessions":                  "Active Sessions",
+  "Physical Read Total IO Requests Per Sec":  "Requests Per Second",
+  "Physical Read Total Bytes Per Sec":        "Bytes Per Second",
+  "Physical Write Total IO Requests Per Sec": "Requests Per Second",
+  "Physical Write Total Bytes Per Sec":       "Bytes Per Second",
+  "Buffer Cache Hit Ratio":                   "% (LogRead - PhyRead)/LogRead",
+  "Library Cache Hit Ratio":                  "% Hits/Pins",
+  "Cursor Cache Hit Ratio":                   "% CursorCacheHit/SoftParse",
+  "Row Cache Hit Ratio":                      "% Hits/Gets",
+  "Database CPU Time Ratio":                  "% Cpu/DB_Time",
+  "Database Wait Time Ratio":                 "% Wait/DB_Time",
+  "User Calls Per Sec":                       "Calls Per Second",
+  "Logons Per Sec":                           "Logons Per Second",
+}
+
+// legacySysmetric and legacyCache are exported as oracledb_sysmetric and oracledb_cachehitratio.
+var (
+  legacySysmetric = []string{
+    "Physical Read Total IO Requests Per Sec",
+    "Physical Read Total Bytes Per Sec",
+    "Physical Write Total IO Requests Per Sec",
+    "Physical Write Total Bytes Per Sec",
+  }
+  legacyCache = []string{
+    "Buffer Cache Hit Ratio",
+    "Cursor Cache Hit Ratio",
+    "Library Cache Hit Ratio",
+    "Row Cache Hit Ratio",
+  }
+)
+
+// defaultEventHistograms are the wait events exported as histograms when no event_histograms are set on the connection.
+var defaultEventHistograms = []string{"log file sync", "db file sequential read"}
+
+// defaultParameters are exported when no parameters are set on the connection.
+var defaultParameters = []string{"sessions"}
+
+// instanceParameters differ between RAC instances by design and are ignored by the mismatch check.
+var instanceParameters = map[string]bool{
+  "instance_name":   true,
+  "instance_number": true,
+  "thread":          true,
+  "undo_tablespace": true,
+  "local_listener":  true,
+  "core_dump_dest":  true,
+  "audit_file_dest": true,
+}
+
+// sessionColumns maps the dimensions allowed in session_groups to the v$session columns.
+var sessionColumns = map[string]string{
+  "service_name": "nvl(service_name,'-')",
+  "username":     "nvl(username,'-')",
+  "machine":      "nvl(machine,'-')",
+  "program":      "nvl(program,'-')",
+  "wait_class":   "nvl(wait_class,'-')",
+  "status":       "status",
+  "state":        "nvl(state,'-')",
+}
+
+// Open modes of the database a collector is safe to run in.
+const (
+  stateMounted = 1 << iota // MOUNTED, only the v$ views are accessible
+  stateReadOnly            // READ ONLY (WITH APPLY), e.g. an Active Data Guard standby
+  stateReadWrite
+
+  stateOpen = stateReadOnly | stateReadWrite
+  stateAny  = stateMounted | stateReadOnly | stateReadWrite
+)
+
+// collectorStates lists the open modes each collector is safe to run in.
+// Collectors missing here only run on databases opened READ WRITE.
+var collectorStates = map[string]int{
+  "uptime":                stateAny,
+  "session":               stateAny,
+  "session_group":         stateAny,
+  "sysstat":               stateAny,
+  "waitclass":             stateAny,
+  "time_model":            stateAny,
+  "service_stats":         stateAny,
+  "system_event":          stateAny,
+  "event_histogram":       stateAny,
+  "sysmetric":             stateAny,
+  "tablespace":            stateOpen,
+  "interconnect":          stateAny,
+  "recovery":              stateAny,
+  "redo":                  stateAny,
+  "services":              stateAny,
+  "parameter":             stateAny,
+  "resource_limit":        stateAny,
+  "parameter_drift":       stateAny,
+  "query":                 stateOpen,
+  "asmspace":              stateAny,
+  "asmdiskgroup":          stateAny,
+  "asmdisk":               stateAny,
+  "asmoperation":          stateAny,
+  "dataguard_info":        stateAny,
+  "dataguard_stats":       stateAny,
+  "archive_dest_status":   stateAny,
+  "managed_standby":       stateAny,
+  "filestat":              stateAny,
+  "sga":                   stateAny,
+  "sga_components":        stateAny,
+  "sga_resize_ops":        stateAny,
+  "pgastat":               stateAny,
+  "osstat":                stateAny,
+  "undostat":              stateAny,
+  "temp_usage":            stateOpen,
+  "sqlstats":              stateAny,
+  "sql_plans":             stateAny,
+  "backup":                stateAny,
+  "block_change_tracking": stateAny,
+  "archived_log":          stateAny,
+  "archive_dest":          stateAny,
+  "recovery_file_dest":    stateAny,
+  "redo_log":              stateAny,
+  "redo_size":             stateAny,
+  "blocking":              stateAny,
+  "blockers":              stateAny,
+  "lock_waits":            stateAny,
+}
+
 // Exporter collects Oracle DB metrics. It implements prometheus.Collector.
 type Exporter struct {
+  // mu serializes overlapping scrapes of the same target, Collect shares the
+  // connection and the state kept between scrapes.
+  mu              sync.Mutex
   duration, error *prometheus.GaugeVec
   totalScrapes    *prometheus.CounterVec
   scrapeErrors    *prometheus.CounterVec
+  skipped         *prometheus.GaugeVec
   session         *prometheus.GaugeVec
-  sysstat         *prometheus.GaugeVec
+  sysstat         *prometheus.Desc
   waitclass       *prometheus.GaugeVec
   sysmetric       *prometheus.GaugeVec
+  sysmetricDescs  map[string]*prometheus.Desc
   interconnect    *prometheus.GaugeVec
   uptime          *prometheus.GaugeVec
   up              *prometheus.GaugeVec
@@ -38,8 +220,68 @@ type Exporter struct {
   cache           *prometheus.GaugeVec
   services        *prometheus.GaugeVec
   parameter       *prometheus.GaugeVec
+  parameterInfo   *prometheus.GaugeVec
+  parameterHash   *prometheus.GaugeVec
+  parameterDrift  *prometheus.GaugeVec
+  parameterChange *prometheus.CounterVec
+  parameterHashes map[string]uint32 // guarded by mu
   query           *prometheus.GaugeVec
   asmspace        *prometheus.GaugeVec
+  asmdiskgroup    *prometheus.GaugeVec
+  asmdisk         *prometheus.GaugeVec
+  asmdiskErrors   *prometheus.Desc
+  asmoperation    *prometheus.GaugeVec
+  dataguard       *prometheus.GaugeVec
+  dataguardInfo   *prometheus.GaugeVec
+  archivedest     *prometheus.GaugeVec
+  archivedestErr  *prometheus.GaugeVec
+  mrp             *prometheus.GaugeVec
+  backup          *prometheus.GaugeVec
+  bct             *prometheus.GaugeVec
+  archivedLogs    *prometheus.CounterVec
+  archivedBytes   *prometheus.CounterVec
+  archivedRecid   int64 // guarded by mu, -1 until the first scrape
+  archivedestInfo *prometheus.GaugeVec
+  archivedestFail *prometheus.GaugeVec
+  recoveryDest    *prometheus.GaugeVec
+  redoGroup       *prometheus.GaugeVec
+  redoGroupStatus *prometheus.GaugeVec
+  redoWaiting     *prometheus.GaugeVec
+  redoSwitches    *prometheus.Desc
+  redoSize        *prometheus.Desc
+  blocking        *prometheus.GaugeVec
+  blocker         *prometheus.GaugeVec
+  lockWaits       *prometheus.GaugeVec
+  sessiongroup    *prometheus.GaugeVec
+  resourceLimit   *prometheus.GaugeVec
+  timeModel       *prometheus.Desc
+  serviceStats    *prometheus.Desc
+  eventWaits      *prometheus.Desc
+  eventTime       *prometheus.Desc
+  eventTimeouts   *prometheus.Desc
+  eventHistogram  *prometheus.Desc
+  sga             *prometheus.GaugeVec
+  sgaComponent    *prometheus.GaugeVec
+  pga             *prometheus.GaugeVec
+  pgaOverAlloc    *prometheus.Desc
+  sgaResize       *prometheus.CounterVec
+  sgaResizeLast   string          // guarded by mu
+  sgaResizeSeen   map[string]bool // guarded by mu
+  osstat          *prometheus.GaugeVec
+  osstatTime      *prometheus.Desc
+  osstatVM        *prometheus.Desc
+  undostat        *prometheus.GaugeVec
+  undoErrors      *prometheus.CounterVec
+  undoLast        string             // guarded by mu
+  undoLastErrors  map[string]float64 // guarded by mu
+  tempUsage       *prometheus.GaugeVec
+  sqlstats        *prometheus.Desc
+  sqlstatsTime    *prometheus.Desc
+  sqlPlan         *prometheus.GaugeVec
+  sqlPlanChange   *prometheus.CounterVec
+  sqlPlans        map[string]map[string]bool // guarded by mu
+  filestat        *prometheus.Desc
+  filestatTime    *prometheus.Desc
   config          Config
 }
 
@@ -89,6 +331,12 @@ func NewExporter() *Exporter {
       Name:      "last_scrape_error",
       Help:      "Whether the last scrape of metrics from Oracle DB resulted in an error (1 for error, 0 for success).",
     },[]string{"database","dbinstance"}),
+    skipped: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Subsystem: exporter,
+      Name:      "collector_skipped",
+      Help:      "Collectors skipped because they are not safe for the role and open mode of the database (e.g. physical_standby_mounted).",
+    },[]string{"database","dbinstance","collector","reason"}),
     sysmetric: prometheus.NewGaugeVec(prometheus.GaugeOpts{
       Namespace: namespace,
       Name:      "sysmetric",
@@ -99,11 +347,10 @@ func NewExporter() *Exporter {
       Name:      "waitclass",
       Help:      "Gauge metric with Waitevents (v$waitclassmetric).",
     }, []string{"database","dbinstance","type"}),
-    sysstat: prometheus.NewGaugeVec(prometheus.GaugeOpts{
-      Namespace: namespace,
-      Name:      "sysstat",
-      Help:      "Gauge metric with commits/rollbacks/parses (v$sysstat).",
-    }, []string{"database","dbinstance","type"}),
+    sysstat: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "sysstat"),
+      "Counter metric with commits/rollbacks/parses/... selected by name (v$sysstat).",
+      []string{"database","dbinstance","type"}, nil),
     session: prometheus.NewGaugeVec(prometheus.GaugeOpts{
       Namespace: namespace,
       Name:      "session",
@@ -117,7 +364,7 @@ func NewExporter() *Exporter {
     tablespace: prometheus.NewGaugeVec(prometheus.GaugeOpts{
       Namespace: namespace,
       Name:      "tablespace",
-      Help:      "Gauge metric with total/free size of the Tablespaces.",
+      Help:      "Gauge metric with total/free/used/max size and usage in percent of max size of the Tablespaces.",
     }, []string{"database","dbinstance","type","name","contents","autoextend"}),
     interconnect: prometheus.NewGaugeVec(prometheus.GaugeOpts{
       Namespace: namespace,
@@ -152,8 +399,28 @@ func NewExporter() *Exporter {
     parameter: prometheus.NewGaugeVec(prometheus.GaugeOpts{
       Namespace: namespace,
       Name:      "parameter",
-      Help:      "oracle Configuration Parameters (v$parameter).",
+      Help:      "oracle Configuration Parameters with numeric values, sizes in bytes (v$parameter).",
+    }, []string{"database","dbinstance","name","isdefault","ismodified"}),
+    parameterInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "parameter_info",
+      Help:      "oracle Configuration Parameters with non-numeric values, always 1 (v$parameter).",
+    }, []string{"database","dbinstance","name","value","isdefault","ismodified"}),
+    parameterHash: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "parameter_hash",
+      Help:      "Hash (FNV-1a) of the non-default Configuration Parameters per instance (gv$parameter).",
+    }, []string{"database","dbinstance","instance_name"}),
+    parameterDrift: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "parameter_mismatch",
+      Help:      "Configuration Parameters with different values across the instances of the database, always 1 (gv$parameter).",
     }, []string{"database","dbinstance","name"}),
+    parameterChange: prometheus.NewCounterVec(prometheus.CounterOpts{
+      Namespace: namespace,
+      Name:      "parameter_changes_total",
+      Help:      "Total number of changes of the non-default Configuration Parameters per instance seen between scrapes (gv$parameter).",
+    }, []string{"database","dbinstance","instance_name"}),
     query: prometheus.NewGaugeVec(prometheus.GaugeOpts{
       Namespace: namespace,
       Name:      "query",
@@ -164,6 +431,231 @@ func NewExporter() *Exporter {
       Name:      "asmspace",
       Help:      "Gauge metric with total/free size of the ASM Diskgroups.",
     }, []string{"database","dbinstance","type","name"}),
+    asmdiskgroup: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "asmdiskgroup_bytes",
+      Help:      "Gauge metric with total/free/usable_file/required_mirror_free bytes of the ASM Diskgroups (v$asm_diskgroup).",
+    }, []string{"database","dbinstance","type","name","redundancy"}),
+    asmdisk: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "asmdisk_status",
+      Help:      "Mount and mode status of the ASM Disks, always 1 (v$asm_disk_stat).",
+    }, []string{"database","dbinstance","diskgroup","name","mount_status","mode_status"}),
+    asmdiskErrors: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "asmdisk_errors_total"),
+      "Counter metric with read/write errors of the ASM Disks (v$asm_disk_stat).",
+      []string{"database","dbinstance","type","diskgroup","name"}, nil),
+    asmoperation: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "asmoperation",
+      Help:      "Gauge metric with power/sofar/est_work/est_minutes of running ASM operations like rebalances per pass (v$asm_operation).",
+    }, []string{"database","dbinstance","type","diskgroup","operation","pass","state"}),
+    dataguard: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "dataguard_seconds",
+      Help:      "Gauge metric with transport lag/apply lag/apply finish time in seconds of the Standby (v$dataguard_stats).",
+    }, []string{"database","dbinstance","type"}),
+    dataguardInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "dataguard_info",
+      Help:      "Database role and protection mode of the Database, always 1 (v$database).",
+    }, []string{"database","dbinstance","role","protection_mode","protection_level"}),
+    archivedest: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "dataguard_archive_dest",
+      Help:      "Status and gap status of the archive destinations, always 1 (v$archive_dest_status).",
+    }, []string{"database","dbinstance","dest","status","gap_status"}),
+    archivedestErr: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "dataguard_archive_dest_error",
+      Help:      "Whether the archive destination reports an error (1 for error, 0 for none) (v$archive_dest_status).",
+    }, []string{"database","dbinstance","dest","error"}),
+    mrp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "dataguard_mrp",
+      Help:      "State of the managed recovery processes, always 1 (v$dataguard_process/v$managed_standby).",
+    }, []string{"database","dbinstance","process","status"}),
+    backup: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "backup",
+      Help:      "Gauge metric with age (seconds since completion)/duration (seconds)/input and output bytes of the last backup per backup type and status (v$rman_backup_job_details/v$backup_set).",
+    }, []string{"database","dbinstance","type","backup_type","status"}),
+    bct: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "block_change_tracking",
+      Help:      "Whether block change tracking is enabled (1 for enabled, 0 for disabled) (v$block_change_tracking).",
+    }, []string{"database","dbinstance","status"}),
+    archivedLogs: prometheus.NewCounterVec(prometheus.CounterOpts{
+      Namespace: namespace,
+      Name:      "archived_logs_total",
+      Help:      "Total number of archived logs generated by the redo thread of the instance per destination (v$archived_log).",
+    }, []string{"database","dbinstance","dest"}),
+    archivedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+      Namespace: namespace,
+      Name:      "archived_logs_bytes_total",
+      Help:      "Total bytes of archived logs generated by the redo thread of the instance per destination (v$archived_log).",
+    }, []string{"database","dbinstance","dest"}),
+    archivedRecid: -1,
+    archivedestInfo: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "archive_dest_info",
+      Help:      "Destination, status and log_archive_dest_state_n of the archive destinations, always 1 (v$archive_dest/v$parameter).",
+    }, []string{"database","dbinstance","dest","destination","status","state"}),
+    archivedestFail: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "archive_dest_failures",
+      Help:      "Number of contiguous archival failures and last error per archive destination (v$archive_dest).",
+    }, []string{"database","dbinstance","dest","error"}),
+    recoveryDest: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "recovery_file_dest_bytes",
+      Help:      "Gauge metric with limit/used/reclaimable bytes of the FRA (v$recovery_file_dest).",
+    }, []string{"database","dbinstance","type","name"}),
+    redoGroup: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "redo_log_group",
+      Help:      "Gauge metric with size_bytes/members of the Redo log groups (v$log/v$logfile).",
+    }, []string{"database","dbinstance","type","group","thread"}),
+    redoGroupStatus: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "redo_log_group_status",
+      Help:      "Status and archived flag of the Redo log groups, always 1 (v$log).",
+    }, []string{"database","dbinstance","group","thread","status","archived"}),
+    redoWaiting: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "redo_log_groups_waiting_archive",
+      Help:      "Number of non current Redo log groups not archived yet, 0 in NOARCHIVELOG mode (v$log).",
+    }, []string{"database","dbinstance","thread"}),
+    redoSwitches: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "redo_log_switches_total"),
+      "Counter metric with Redo log switches per thread, the highest log sequence (v$log).",
+      []string{"database","dbinstance","thread"}, nil),
+    redoSize: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "redo_size_bytes_total"),
+      "Counter metric with the bytes of Redo generated (v$sysstat).",
+      []string{"database","dbinstance"}, nil),
+    blocking: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "blocking",
+      Help:      "Gauge metric with blocked_sessions/max_wait_seconds/max_depth of the blocking trees (v$session).",
+    }, []string{"database","dbinstance","type"}),
+    blocker: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "blocker",
+      Help:      "Number of sessions waiting on the top blocking sessions (v$session).",
+    }, []string{"database","dbinstance","sid","username","program","sql_id"}),
+    lockWaits: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "lock_waits",
+      Help:      "Number of sessions waiting for a lock per lock type (v$lock).",
+    }, []string{"database","dbinstance","type"}),
+    resourceLimit: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "resource_limit",
+      Help:      "Gauge metric with current_utilization/max_utilization/limit_value of the resources, no limit_value for UNLIMITED (v$resource_limit).",
+    }, []string{"database","dbinstance","type","name"}),
+    timeModel: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "time_model_seconds_total"),
+      "Counter metric with the cumulative time statistics like db_time/db_cpu in seconds (v$sys_time_model).",
+      []string{"database","dbinstance","type"}, nil),
+    serviceStats: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "service_time_model_seconds_total"),
+      "Counter metric with the cumulative time statistics like db_time/db_cpu in seconds per service (v$service_stats).",
+      []string{"database","dbinstance","type","service"}, nil),
+    eventWaits: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "system_event_waits_total"),
+      "Counter metric with the number of waits per wait event (v$system_event).",
+      []string{"database","dbinstance","event","wait_class"}, nil),
+    eventTime: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "system_event_wait_seconds_total"),
+      "Counter metric with the time waited in seconds per wait event (v$system_event).",
+      []string{"database","dbinstance","event","wait_class"}, nil),
+    eventTimeouts: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "system_event_timeouts_total"),
+      "Counter metric with the number of timeouts per wait event (v$system_event).",
+      []string{"database","dbinstance","event","wait_class"}, nil),
+    eventHistogram: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "event_wait_seconds"),
+      "Histogram of the wait times of the configured wait events (v$event_histogram_micro/v$event_histogram).",
+      []string{"database","dbinstance","event"}, nil),
+    sga: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "sga_bytes",
+      Help:      "Gauge metric with the sizes of the SGA and the free memory of the shared pool (v$sgainfo/v$sgastat).",
+    }, []string{"database","dbinstance","name"}),
+    sgaComponent: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "sga_component_bytes",
+      Help:      "Gauge metric with current/min/max size of the dynamic SGA components (v$sga_dynamic_components).",
+    }, []string{"database","dbinstance","type","component"}),
+    pga: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "pga_bytes",
+      Help:      "Gauge metric with aggregate target/allocated/inuse bytes of the PGA (v$pgastat).",
+    }, []string{"database","dbinstance","type"}),
+    pgaOverAlloc: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "pga_over_allocation_total"),
+      "Counter metric with the number of times the PGA was over allocated (v$pgastat).",
+      []string{"database","dbinstance"}, nil),
+    sgaResize: prometheus.NewCounterVec(prometheus.CounterOpts{
+      Namespace: namespace,
+      Name:      "sga_resize_ops_total",
+      Help:      "Total number of resize operations per SGA component and operation type (v$sga_resize_ops).",
+    }, []string{"database","dbinstance","component","oper_type"}),
+    osstat: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "osstat",
+      Help:      "Gauge metric with num_cpus/load/physical_memory_bytes of the host (v$osstat).",
+    }, []string{"database","dbinstance","type"}),
+    osstatTime: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "osstat_cpu_seconds_total"),
+      "Counter metric with busy/idle/iowait/user/sys CPU time of the host in seconds, summed over all CPUs (v$osstat).",
+      []string{"database","dbinstance","type"}, nil),
+    osstatVM: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "osstat_vm_bytes_total"),
+      "Counter metric with the bytes paged in/out of the host (v$osstat).",
+      []string{"database","dbinstance","type"}, nil),
+    undostat: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "undostat",
+      Help:      "Gauge metric with maxquerylen/tuned_undoretention (seconds) of the current 10 minute interval (v$undostat).",
+    }, []string{"database","dbinstance","type"}),
+    undoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+      Namespace: namespace,
+      Name:      "undostat_errors_total",
+      Help:      "Total number of ssolderrcnt (ORA-01555)/nospaceerrcnt errors (v$undostat).",
+    }, []string{"database","dbinstance","type"}),
+    tempUsage: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "tempseg_usage_bytes",
+      Help:      "Gauge metric with the bytes of temporary segments in use per username and segment type (v$tempseg_usage).",
+    }, []string{"database","dbinstance","username","segtype"}),
+    sqlstats: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "sqlstats_total"),
+      "Counter metric with executions/buffer_gets/disk_reads/rows_processed of the top SQL statements (v$sqlstats).",
+      []string{"database","dbinstance","type","sql_id","sql_text"}, nil),
+    sqlstatsTime: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "sqlstats_seconds_total"),
+      "Counter metric with elapsed/cpu time in seconds of the top SQL statements (v$sqlstats).",
+      []string{"database","dbinstance","type","sql_id","sql_text"}, nil),
+    sqlPlan: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "sql_plan_elapsed_seconds_per_execution",
+      Help:      "Elapsed time per execution of the current plans of the tracked SQL statements (v$sql).",
+    }, []string{"database","dbinstance","sql_id","plan_hash_value"}),
+    sqlPlanChange: prometheus.NewCounterVec(prometheus.CounterOpts{
+      Namespace: namespace,
+      Name:      "sql_plan_changes_total",
+      Help:      "Total number of new plans of the tracked SQL statements seen between scrapes (v$sql).",
+    }, []string{"database","dbinstance","sql_id"}),
+    filestat: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "filestat_total"),
+      "Counter metric with physical reads/writes and blocks read/written per datafile and tempfile (v$filestat/v$tempstat).",
+      []string{"database","dbinstance","type","filetype","file","tablespace"}, nil),
+    filestatTime: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "", "filestat_seconds_total"),
+      "Counter metric with time spent reading/writing per datafile and tempfile (v$filestat/v$tempstat).",
+      []string{"database","dbinstance","type","filetype","file","tablespace"}, nil),
   }
 }
 
@@ -202,12 +694,21 @@ func (e *Exporter) ScrapeParameter() {
     err  error
   )
 
-  db := e.config.db
+  config := e.config
+  db := config.db
+
+  parameters := config.Parameters
+  if len(parameters) == 0 {
+    parameters = defaultParameters
+  }
+  names := make([]string, len(parameters))
+  for i, name := range parameters {
+    names[i] = strings.ToLower(name)
+  }
 
-  //num  metric_name
-  //43  sessions
   if db != nil {
-    rows, err = db.Query(`select name,value from v$parameter WHERE num=43`)
+    rows, err = db.Query(`select name,value,isdefault,ismodified from v$parameter
+                               WHERE name in (` + sqlList(names) + `)`)
     if err != nil {
       return
     }
@@ -216,19 +717,29 @@ func (e *Exporter) ScrapeParameter() {
 
     for rows.Next() {
       var name string
-      var value float64
-      if err := rows.Scan(&name,&value); err != nil {
+      var value sql.NullString
+      var isdefault string
+      var ismodified string
+      if err := rows.Scan(&name,&value,&isdefault,&ismodified); err != nil {
         break
       }
+      if !config.ParameterFlags {
+        isdefault, ismodified = "", ""
+      }
       name = cleanName(name)
-      e.parameter.WithLabelValues(e.config.Database,e.config.Instance,name).Set(value)
+      // Numeric values (incl. sizes like 4G) become gauges, everything else an info metric.
+      if number, err := parseSize(value.String); err == nil {
+        e.parameter.WithLabelValues(config.Database,config.Instance,name,isdefault,ismodified).Set(number)
+      } else {
+        e.parameterInfo.WithLabelValues(config.Database,config.Instance,name,value.String,isdefault,ismodified).Set(1)
+      }
     }
   }
 }
 
 
-// ScrapeServices collects metrics from the v$active_services view.
-func (e *Exporter) ScrapeServices() {
+// ScrapeParameterDrift detects changed parameters and parameters differing across the RAC instances.
+func (e *Exporter) ScrapeParameterDrift() {
   var (
     rows *sql.Rows
     err  error
@@ -238,25 +749,103 @@ func (e *Exporter) ScrapeServices() {
   db := config.db
 
   if db != nil {
-    rows, err = db.Query(`select name from v$active_services`)
+    rows, err = db.Query(`SELECT i.instance_name, p.name, nvl(p.value,' ')
+                               FROM gv$parameter p, gv$instance i
+                               WHERE p.inst_id = i.inst_id AND p.isdefault = 'FALSE'
+                               ORDER BY i.instance_name, p.name`)
     if err != nil {
       return
     }
     defer rows.Close()
+
+    hashes := map[string]hash.Hash32{}
+    values := map[string]map[string]string{}
     for rows.Next() {
+      var instance string
       var name string
-      if err := rows.Scan(&name); err != nil {
+      var value string
+      if err := rows.Scan(&instance, &name, &value); err != nil {
+        return
+      }
+      if hashes[instance] == nil {
+        hashes[instance] = fnv.New32a()
+      }
+      fmt.Fprintf(hashes[instance], "%s=%s\n", name, value)
+      if values[name] == nil {
+        values[name] = map[string]string{}
+      }
+      values[name][instance] = value
+    }
+
+    if e.parameterHashes == nil {
+      e.parameterHashes = map[string]uint32{}
+    }
+    for instance, h := range hashes {
+      sum := h.Sum32()
+      e.parameterHash.WithLabelValues(config.Database,config.Instance,instance).Set(float64(sum))
+      changes := e.parameterChange.WithLabelValues(config.Database,config.Instance,instance)
+      if last, ok := e.parameterHashes[instance]; ok && last != sum {
+        changes.Inc()
+      }
+      e.parameterHashes[instance] = sum
+    }
+
+    // A parameter set on some instances only counts as mismatch as well.
+    for name, byInstance := range values {
+      if instanceParameters[name] || len(hashes) < 2 {
+        continue
+      }
+      distinct := map[string]bool{}
+      for _, value := range byInstance {
+        distinct[value] = true
+      }
+      if len(byInstance) != len(hashes) || len(distinct) > 1 {
+        e.parameterDrift.WithLabelValues(config.Database,config.Instance,name).Set(1)
+      }
+    }
+  }
+}
+
+
+// ScrapeResourceLimit collects the utilization and limits of processes, sessions, ... from the v$resource_limit view.
+func (e *Exporter) ScrapeResourceLimit() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT resource_name, current_utilization, max_utilization, trim(limit_value)
+                               FROM v$resource_limit`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var name string
+      var current float64
+      var max float64
+      var limit string
+      if err := rows.Scan(&name, &current, &max, &limit); err != nil {
         break
       }
       name = cleanName(name)
-      e.services.WithLabelValues(config.Database,config.Instance,name).Set(1)
+      e.resourceLimit.WithLabelValues(config.Database,config.Instance,"current_utilization",name).Set(current)
+      e.resourceLimit.WithLabelValues(config.Database,config.Instance,"max_utilization",name).Set(max)
+      // limit_value is UNLIMITED for resources without a limit.
+      if value, err := strconv.ParseFloat(limit, 64); err == nil {
+        e.resourceLimit.WithLabelValues(config.Database,config.Instance,"limit_value",name).Set(value)
+      }
     }
   }
 }
 
 
-// ScrapeCache collects session metrics from the v$sysmetrics view.
-func (e *Exporter) ScrapeCache() {
+// ScrapeServices collects metrics from the v$active_services view.
+func (e *Exporter) ScrapeServices() {
   var (
     rows *sql.Rows
     err  error
@@ -265,28 +854,19 @@ func (e *Exporter) ScrapeCache() {
   config := e.config
   db := config.db
 
-  //metric_id  metric_name
-  //2000    Buffer Cache Hit Ratio
-  //2050    Cursor Cache Hit Ratio
-  //2112    Library Cache Hit Ratio
-  //2110    Row Cache Hit Ratio
-
   if db != nil {
-    rows, err = db.Query(`select metric_name,value
-                               from v$sysmetric
-                               where group_id=2 and metric_id in (2000,2050,2112,2110)`)
+    rows, err = db.Query(`select name from v$active_services`)
     if err != nil {
       return
     }
     defer rows.Close()
     for rows.Next() {
       var name string
-      var value float64
-      if err := rows.Scan(&name, &value); err != nil {
+      if err := rows.Scan(&name); err != nil {
         break
       }
       name = cleanName(name)
-      e.cache.WithLabelValues(config.Database,config.Instance,name).Set(value)
+      e.services.WithLabelValues(config.Database,config.Instance,name).Set(1)
     }
   }
 }
@@ -404,16 +984,22 @@ func (e *Exporter) ScrapeAsmspace() {
       if err := rows.Scan(&name, &tsize, &tfree); err != nil {
         break
       }
-      e.asmspace.WithLabelValues(config.Database,config.Instance,"total",name).Set(tsize)
-      e.asmspace.WithLabelValues(config.Database,config.Instance,"free",name).Set(tfree)
-      e.asmspace.WithLabelValues(config.Database,config.Instance,"used",name).Set(tsize-tfree)
+      if !config.Diskgroups.Keep(name) {
+        if !config.Diskgroups.Other {
+          continue
+        }
+        name = "other"
+      }
+      e.asmspace.WithLabelValues(config.Database,config.Instance,"total",name).Add(tsize)
+      e.asmspace.WithLabelValues(config.Database,config.Instance,"free",name).Add(tfree)
+      e.asmspace.WithLabelValues(config.Database,config.Instance,"used",name).Add(tsize-tfree)
     }
   }
 }
 
 
-// ScrapeTablespaces collects tablespace metrics
-func (e *Exporter) ScrapeTablespace() {
+// ScrapeAsmdiskgroup collects redundancy aware space metrics of the ASM diskgroups
+func (e *Exporter) ScrapeAsmdiskgroup() {
   var (
     rows *sql.Rows
     err  error
@@ -423,42 +1009,36 @@ func (e *Exporter) ScrapeTablespace() {
   db := config.db
 
   if db != nil {
-    rows, err = db.Query(`WITH
-                                 getsize AS (SELECT tablespace_name, autoextensible, SUM(bytes) tsize
-                                             FROM dba_data_files GROUP BY tablespace_name, autoextensible),
-                                 getfree as (SELECT tablespace_name, contents, SUM(blocks*block_size) tfree
-                                             FROM DBA_LMT_FREE_SPACE a, v$tablespace b, dba_tablespaces c
-                                             WHERE a.TABLESPACE_ID= b.ts# and b.name=c.tablespace_name
-                                             GROUP BY tablespace_name,contents)
-                               SELECT a.tablespace_name, b.contents, a.tsize,  b.tfree, a.autoextensible autoextend
-                               FROM GETSIZE a, GETFREE b
-                               WHERE a.tablespace_name = b.tablespace_name
-                               UNION
-                               SELECT tablespace_name, 'TEMPORARY', sum(tablespace_size), sum(free_space), 'NO'
-                               FROM dba_temp_free_space
-                               GROUP BY tablespace_name`)
+    // usable_file_mb already accounts for the mirroring of NORMAL/HIGH redundancy diskgroups.
+    rows, err = db.Query(`SELECT name, type, total_mb, free_mb, usable_file_mb, required_mirror_free_mb
+                               FROM v$asm_diskgroup_stat`)
     if err != nil {
       return
     }
     defer rows.Close()
     for rows.Next() {
       var name string
-      var contents string
-      var tsize float64
-      var tfree float64
-      var auto string
-      if err := rows.Scan(&name, &contents, &tsize, &tfree, &auto); err != nil {
+      var redundancy string
+      var total, free, usable, mirror float64
+      if err := rows.Scan(&name, &redundancy, &total, &free, &usable, &mirror); err != nil {
         break
       }
-      e.tablespace.WithLabelValues(config.Database,config.Instance,"total",name,contents,auto).Set(tsize)
-      e.tablespace.WithLabelValues(config.Database,config.Instance,"free",name,contents,auto).Set(tfree)
-      e.tablespace.WithLabelValues(config.Database,config.Instance,"used",name,contents,auto).Set(tsize-tfree)
+      if !config.Diskgroups.Keep(name) {
+        if !config.Diskgroups.Other {
+          continue
+        }
+        name = "other"
+      }
+      e.asmdiskgroup.WithLabelValues(config.Database,config.Instance,"total",name,redundancy).Add(total*1024*1024)
+      e.asmdiskgroup.WithLabelValues(config.Database,config.Instance,"free",name,redundancy).Add(free*1024*1024)
+      e.asmdiskgroup.WithLabelValues(config.Database,config.Instance,"usable_file",name,redundancy).Add(usable*1024*1024)
+      e.asmdiskgroup.WithLabelValues(config.Database,config.Instance,"required_mirror_free",name,redundancy).Add(mirror*1024*1024)
     }
   }
 }
 
-// ScrapeSessions collects session metrics from the v$session view.
-func (e *Exporter) ScrapeSession() {
+// ScrapeAsmdisk collects status and error counts of the ASM disks
+func (e *Exporter) ScrapeAsmdisk(ch chan<- prometheus.Metric) {
   var (
     rows *sql.Rows
     err  error
@@ -468,50 +1048,81 @@ func (e *Exporter) ScrapeSession() {
   db := config.db
 
   if db != nil {
-    rows, err = db.Query(`SELECT decode(username,NULL,'SYSTEM','SYS','SYSTEM','USER'), status,count(*)
-                               FROM v$session
-                               GROUP BY decode(username,NULL,'SYSTEM','SYS','SYSTEM','USER'),status`)
+    rows, err = db.Query(`SELECT g.name, nvl(d.name,d.path), d.mount_status, d.mode_status, d.read_errs, d.write_errs
+                               FROM v$asm_disk_stat d, v$asm_diskgroup_stat g
+                               WHERE d.group_number = g.group_number(+)`)
     if err != nil {
       return
     }
     defer rows.Close()
     for rows.Next() {
-      var user string
-      var status string
-      var value float64
-      if err := rows.Scan(&user, &status, &value); err != nil {
+      var group sql.NullString
+      var name string
+      var mount string
+      var mode string
+      var readErrs float64
+      var writeErrs float64
+      if err := rows.Scan(&group, &name, &mount, &mode, &readErrs, &writeErrs); err != nil {
         break
       }
-      e.session.WithLabelValues(config.Database,config.Instance,user,status).Set(value)
+      if group.Valid && !config.Diskgroups.Keep(group.String) {
+        continue
+      }
+      e.asmdisk.WithLabelValues(config.Database,config.Instance,group.String,name,mount,mode).Set(1)
+      ch <- prometheus.MustNewConstMetric(e.asmdiskErrors, prometheus.CounterValue, readErrs, config.Database, config.Instance, "read", group.String, name)
+      ch <- prometheus.MustNewConstMetric(e.asmdiskErrors, prometheus.CounterValue, writeErrs, config.Database, config.Instance, "write", group.String, name)
     }
   }
 }
 
-
-// ScrapeUptime Instance uptime
-func (e *Exporter) ScrapeUptime() {
-  var uptime float64
+// ScrapeAsmoperation collects the progress of running ASM operations (rebalance, resync, ...),
+// from 12c there is a row per pass of an operation.
+func (e *Exporter) ScrapeAsmoperation() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
 
   config := e.config
   db := config.db
 
   if db != nil {
-    rows, err := db.Query("select sysdate-startup_time from v$instance")
+    rows, err = db.Query(`SELECT g.name, o.operation, nvl(o.pass,'-'), o.state, nvl(o.power,0), nvl(o.sofar,0),
+                                 nvl(o.est_work,0), nvl(o.est_minutes,0)
+                               FROM v$asm_operation o, v$asm_diskgroup_stat g
+                               WHERE o.group_number = g.group_number`)
     if err != nil {
-      return
+      rows, err = db.Query(`SELECT g.name, o.operation, '-', o.state, nvl(o.power,0), nvl(o.sofar,0),
+                                   nvl(o.est_work,0), nvl(o.est_minutes,0)
+                                 FROM v$asm_operation o, v$asm_diskgroup_stat g
+                                 WHERE o.group_number = g.group_number`)
+      if err != nil {
+        return
+      }
     }
-
     defer rows.Close()
-    rows.Next()
-    err = rows.Scan(&uptime)
-    if err == nil {
-      e.uptime.WithLabelValues(config.Database,config.Instance).Set(uptime)
+    for rows.Next() {
+      var group string
+      var operation string
+      var pass string
+      var state string
+      var power, sofar, estWork, estMinutes float64
+      if err := rows.Scan(&group, &operation, &pass, &state, &power, &sofar, &estWork, &estMinutes); err != nil {
+        break
+      }
+      if !config.Diskgroups.Keep(group) {
+        continue
+      }
+      e.asmoperation.WithLabelValues(config.Database,config.Instance,"power",group,operation,pass,state).Set(power)
+      e.asmoperation.WithLabelValues(config.Database,config.Instance,"sofar",group,operation,pass,state).Set(sofar)
+      e.asmoperation.WithLabelValues(config.Database,config.Instance,"est_work",group,operation,pass,state).Set(estWork)
+      e.asmoperation.WithLabelValues(config.Database,config.Instance,"est_minutes",group,operation,pass,state).Set(estMinutes)
     }
   }
 }
 
-// ScrapeSysstat collects activity metrics from the v$sysstat view.
-func (e *Exporter) ScrapeSysstat() {
+// ScrapeDataguardInfo collects role and protection mode of the database
+func (e *Exporter) ScrapeDataguardInfo() {
   var (
     rows *sql.Rows
     err  error
@@ -521,26 +1132,23 @@ func (e *Exporter) ScrapeSysstat() {
   db := config.db
 
   if db != nil {
-    rows, err = db.Query(`SELECT name, value FROM v$sysstat
-                                    WHERE statistic# in (6,7,1084,1089)`)
+    rows, err = db.Query(`SELECT database_role, protection_mode, protection_level FROM v$database`)
     if err != nil {
       return
     }
     defer rows.Close()
     for rows.Next() {
-      var name string
-      var value float64
-      if err := rows.Scan(&name, &value); err != nil {
+      var role, mode, level string
+      if err := rows.Scan(&role, &mode, &level); err != nil {
         break
       }
-      name = cleanName(name)
-      e.sysstat.WithLabelValues(config.Database,config.Instance,name).Set(value)
+      e.dataguardInfo.WithLabelValues(config.Database,config.Instance,role,mode,level).Set(1)
     }
   }
 }
 
-// ScrapeWaitTime collects wait time metrics from the v$waitclassmetric view.
-func (e *Exporter) ScrapeWaitclass() {
+// ScrapeDataguardStats collects transport and apply lag of a standby database
+func (e *Exporter) ScrapeDataguardStats() {
   var (
     rows *sql.Rows
     err  error
@@ -550,27 +1158,31 @@ func (e *Exporter) ScrapeWaitclass() {
   db := config.db
 
   if db != nil {
-    rows, err = db.Query(`SELECT n.wait_class, round(m.time_waited/m.INTSIZE_CSEC,3)
-                                  FROM v$waitclassmetric  m, v$system_wait_class n
-                                  WHERE m.wait_class_id=n.wait_class_id and n.wait_class != 'Idle'`)
+    rows, err = db.Query(`SELECT name, value FROM v$dataguard_stats
+                               WHERE name in ('transport lag','apply lag','apply finish time')
+                                 AND value is not null`)
     if err != nil {
       return
     }
     defer rows.Close()
     for rows.Next() {
       var name string
-      var value float64
+      var value string
       if err := rows.Scan(&name, &value); err != nil {
         break
       }
+      seconds, err := parseInterval(value)
+      if err != nil {
+        continue
+      }
       name = cleanName(name)
-      e.waitclass.WithLabelValues(config.Database,config.Instance,name).Set(value)
+      e.dataguard.WithLabelValues(config.Database,config.Instance,name).Set(seconds)
     }
   }
 }
 
-// ScrapeSysmetrics collects session metrics from the v$sysmetrics view.
-func (e *Exporter) ScrapeSysmetric() {
+// ScrapeArchiveDestStatus collects status, gaps and errors of the archive destinations
+func (e *Exporter) ScrapeArchiveDestStatus() {
   var (
     rows *sql.Rows
     err  error
@@ -579,88 +1191,1605 @@ func (e *Exporter) ScrapeSysmetric() {
   config := e.config
   db := config.db
 
-  //metric_id  metric_name
-  //2092    Physical Read Total IO Requests Per Sec
-  //2093    Physical Read Total Bytes Per Sec
-  //2100    Physical Write Total IO Requests Per Sec
-  //2124    Physical Write Total Bytes Per Sec
   if db != nil {
-    rows, err = db.Query("select metric_name,value from v$sysmetric where metric_id in (2092,2093,2124,2100)")
+    rows, err = db.Query(`SELECT dest_name, status, nvl(gap_status,'NONE'), error
+                               FROM v$archive_dest_status
+                               WHERE status != 'INACTIVE'`)
     if err != nil {
       return
     }
     defer rows.Close()
     for rows.Next() {
-      var name string
-      var value float64
-      if err := rows.Scan(&name, &value); err != nil {
+      var dest string
+      var status string
+      var gap string
+      var msg sql.NullString
+      if err := rows.Scan(&dest, &status, &gap, &msg); err != nil {
         break
       }
-      name = cleanName(name)
-      e.sysmetric.WithLabelValues(config.Database,config.Instance,name).Set(value)
+      dest = cleanName(dest)
+      e.archivedest.WithLabelValues(config.Database,config.Instance,dest,status,gap).Set(1)
+      if msg.String != "" {
+        e.archivedestErr.WithLabelValues(config.Database,config.Instance,dest,msg.String).Set(1)
+      } else {
+        e.archivedestErr.WithLabelValues(config.Database,config.Instance,dest,"").Set(0)
+      }
     }
   }
 }
 
-// Describe describes all the metrics exported by the Oracle exporter.
-func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
-  e.up.Describe(ch)
-  e.session.Describe(ch)
-  e.sysstat.Describe(ch)
-  e.duration.Describe(ch)
-  e.totalScrapes.Describe(ch)
-  e.scrapeErrors.Describe(ch)
-  e.waitclass.Describe(ch)
-  e.sysmetric.Describe(ch)
-  e.interconnect.Describe(ch)
-  e.tablespace.Describe(ch)
-  e.recovery.Describe(ch)
-  e.redo.Describe(ch)
-  e.cache.Describe(ch)
-  e.uptime.Describe(ch)
-  e.services.Describe(ch)
-  e.parameter.Describe(ch)
-  e.query.Describe(ch)
-  e.asmspace.Describe(ch)
-}
-
-// Connect the DBs and gather Databasename and Instancename
-func (e *Exporter) Connect() {
-  e.up.Reset()
-  e.session.Reset()
-  e.sysstat.Reset()
-  e.waitclass.Reset()
-  e.sysmetric.Reset()
-  e.interconnect.Reset()
-  e.tablespace.Reset()
-  e.recovery.Reset()
-  e.redo.Reset()
-  e.cache.Reset()
-  e.uptime.Reset()
-  e.services.Reset()
-  e.parameter.Reset()
-  e.query.Reset()
-  e.asmspace.Reset()
-
-  config := &e.config
-
-  dsn := fmt.Sprintf("%s/%s@%s", config.User, config.Password, config.Connection)
-  db , err := sql.Open("ora", dsn)
-  config.db = db
+// ScrapeManagedStandby collects the state of the managed recovery process (MRP)
+func (e *Exporter) ScrapeManagedStandby() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
 
-  if err != nil {
-    log.Infoln(err)
-    e.up.WithLabelValues(config.Database,config.Instance).Set(0)
+  config := e.config
+  db := config.db
 
-    if db != nil {
-      db.Close()
+  if db != nil {
+    // v$dataguard_process replaces v$managed_standby as of 12.2.
+    rows, err = db.Query(`SELECT name, action FROM v$dataguard_process WHERE name like 'MRP%'`)
+    if err != nil {
+      rows, err = db.Query(`SELECT process, status FROM v$managed_standby WHERE process like 'MRP%'`)
+      if err != nil {
+        return
+      }
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var process string
+      var status string
+      if err := rows.Scan(&process, &status); err != nil {
+        break
+      }
+      e.mrp.WithLabelValues(config.Database,config.Instance,process,status).Set(1)
+    }
+  }
+}
+
+// ScrapeBackup collects the last RMAN backup per backup type
+func (e *Exporter) ScrapeBackup() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  //backup_type  input_type / backup set
+  //full         DB FULL, level 0 is reported as incremental_level_0
+  //incremental  DB INCR, split up by the incremental level of the backup sets
+  //archivelog   ARCHIVELOG
+  //controlfile  CONTROLFILE, SPFILE
+  if db != nil {
+    rows, err = db.Query(`WITH
+                                 jobs AS (SELECT j.session_key, j.session_recid, j.session_stamp,
+                                                 j.input_type, j.status, j.end_time, j.elapsed_seconds,
+                                                 j.input_bytes, j.output_bytes
+                                          FROM v$rman_backup_job_details j
+                                          WHERE j.end_time is not null),
+                                 levels AS (SELECT d.session_key, max(s.incremental_level) incremental_level
+                                            FROM v$backup_set_details d, v$backup_set s
+                                            WHERE d.set_stamp = s.set_stamp AND d.set_count = s.set_count
+                                            GROUP BY d.session_key),
+                                 typed AS (SELECT CASE
+                                                    WHEN j.input_type IN ('DB FULL','DATAFILE FULL') THEN 'full'
+                                                    WHEN j.input_type IN ('DB INCR','DATAFILE INCR') THEN 'incremental_level_'||nvl(l.incremental_level,0)
+                                                    WHEN j.input_type IN ('ARCHIVELOG') THEN 'archivelog'
+                                                    WHEN j.input_type IN ('CONTROLFILE','SPFILE') THEN 'controlfile'
+                                                    ELSE lower(j.input_type)
+                                                  END backup_type,
+                                                  j.status, j.end_time, j.elapsed_seconds, j.input_bytes, j.output_bytes
+                                           FROM jobs j, levels l
+                                           WHERE j.session_key = l.session_key(+))
+                               SELECT backup_type, status,
+                                      (sysdate - max(end_time))*86400,
+                                      max(elapsed_seconds) keep (dense_rank last order by end_time),
+                                      max(input_bytes) keep (dense_rank last order by end_time),
+                                      max(output_bytes) keep (dense_rank last order by end_time)
+                               FROM typed
+                               GROUP BY backup_type, status`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var backupType string
+      var status string
+      var age, duration, input, output float64
+      if err := rows.Scan(&backupType, &status, &age, &duration, &input, &output); err != nil {
+        break
+      }
+      status = cleanName(status)
+      e.backup.WithLabelValues(config.Database,config.Instance,"age_seconds",backupType,status).Set(age)
+      e.backup.WithLabelValues(config.Database,config.Instance,"duration_seconds",backupType,status).Set(duration)
+      e.backup.WithLabelValues(config.Database,config.Instance,"input_bytes",backupType,status).Set(input)
+      e.backup.WithLabelValues(config.Database,config.Instance,"output_bytes",backupType,status).Set(output)
+    }
+  }
+}
+
+// ScrapeBlockChangeTracking collects the status of block change tracking
+func (e *Exporter) ScrapeBlockChangeTracking() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT status FROM v$block_change_tracking`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var status string
+      if err := rows.Scan(&status); err != nil {
+        break
+      }
+      if status == "ENABLED" {
+        e.bct.WithLabelValues(config.Database,config.Instance,cleanName(status)).Set(1)
+      } else {
+        e.bct.WithLabelValues(config.Database,config.Instance,cleanName(status)).Set(0)
+      }
+    }
+  }
+}
+
+// ScrapeArchivedLog counts the archived logs generated since the last scrape
+func (e *Exporter) ScrapeArchivedLog() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  // v$archived_log is aged out with the controlfile records, so only the logs
+  // archived since the last scrape are added to the counters. The first scrape
+  // only takes the watermark, the logs archived before the exporter started are
+  // not counted. On RAC every instance only counts its own thread, so sum()
+  // gives the database total.
+  if db != nil {
+    rows, err = db.Query(fmt.Sprintf(`SELECT d.dest_name, max(a.recid), count(*), sum(a.blocks*a.block_size)
+                               FROM v$archived_log a, v$archive_dest d
+                               WHERE a.dest_id = d.dest_id
+                                 AND a.standby_dest = 'NO'
+                                 AND a.thread# = (SELECT thread# FROM v$instance)
+                                 AND a.recid > %d
+                               GROUP BY d.dest_name`, e.archivedRecid))
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    recid := e.archivedRecid
+    for rows.Next() {
+      var dest string
+      var maxRecid int64
+      var count float64
+      var bytes float64
+      if err := rows.Scan(&dest, &maxRecid, &count, &bytes); err != nil {
+        break
+      }
+      dest = cleanName(dest)
+      if e.archivedRecid < 0 {
+        count, bytes = 0, 0
+      }
+      e.archivedLogs.WithLabelValues(config.Database,config.Instance,dest).Add(count)
+      e.archivedBytes.WithLabelValues(config.Database,config.Instance,dest).Add(bytes)
+      if maxRecid > recid {
+        recid = maxRecid
+      }
+    }
+    if recid < 0 {
+      recid = 0
+    }
+    e.archivedRecid = recid
+  }
+}
+
+// ScrapeArchiveDest collects state and failures of the archive destinations
+func (e *Exporter) ScrapeArchiveDest() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT d.dest_name, nvl(d.destination,'-'), d.status, nvl(upper(p.value),'ENABLE'),
+                                 d.failure_count, d.error
+                               FROM v$archive_dest d, v$parameter p
+                               WHERE p.name(+) = 'log_archive_dest_state_'||d.dest_id
+                                 AND d.status != 'INACTIVE'`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var dest string
+      var destination string
+      var status string
+      var state string
+      var failures float64
+      var msg sql.NullString
+      if err := rows.Scan(&dest, &destination, &status, &state, &failures, &msg); err != nil {
+        break
+      }
+      dest = cleanName(dest)
+      e.archivedestInfo.WithLabelValues(config.Database,config.Instance,dest,destination,status,state).Set(1)
+      e.archivedestFail.WithLabelValues(config.Database,config.Instance,dest,msg.String).Set(failures)
+    }
+  }
+}
+
+// ScrapeRecoveryDest collects the space usage of the FRA in bytes
+func (e *Exporter) ScrapeRecoveryDest() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT name, space_limit, space_used, space_reclaimable FROM v$recovery_file_dest`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var name string
+      var limit, used, recl float64
+      if err := rows.Scan(&name, &limit, &used, &recl); err != nil {
+        break
+      }
+      e.recoveryDest.WithLabelValues(config.Database,config.Instance,"limit",name).Set(limit)
+      e.recoveryDest.WithLabelValues(config.Database,config.Instance,"used",name).Set(used)
+      e.recoveryDest.WithLabelValues(config.Database,config.Instance,"reclaimable",name).Set(recl)
+    }
+  }
+}
+
+// ScrapeRedoLog collects layout, status and log switches of the Redo log groups
+func (e *Exporter) ScrapeRedoLog(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT l.group#, l.thread#, l.sequence#, l.bytes, f.members, l.status, l.archived, d.log_mode
+                               FROM v$log l,
+                                    (SELECT group#, count(*) members FROM v$logfile GROUP BY group#) f,
+                                    v$database d
+                               WHERE l.group# = f.group#`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    switches := map[string]float64{}
+    for rows.Next() {
+      var group string
+      var thread string
+      var sequence float64
+      var bytes float64
+      var members float64
+      var status string
+      var archived string
+      var logMode string
+      if err := rows.Scan(&group, &thread, &sequence, &bytes, &members, &status, &archived, &logMode); err != nil {
+        break
+      }
+      e.redoGroup.WithLabelValues(config.Database,config.Instance,"size_bytes",group,thread).Set(bytes)
+      e.redoGroup.WithLabelValues(config.Database,config.Instance,"members",group,thread).Set(members)
+      e.redoGroupStatus.WithLabelValues(config.Database,config.Instance,group,thread,status,archived).Set(1)
+      waiting := e.redoWaiting.WithLabelValues(config.Database,config.Instance,thread)
+      // in NOARCHIVELOG the groups are never archived, nothing is waiting
+      if logMode == "ARCHIVELOG" && archived == "NO" && status != "CURRENT" && status != "UNUSED" {
+        waiting.Inc()
+      }
+      if sequence > switches[thread] {
+        switches[thread] = sequence
+      }
+    }
+    for thread, sequence := range switches {
+      ch <- prometheus.MustNewConstMetric(e.redoSwitches, prometheus.CounterValue, sequence, config.Database, config.Instance, thread)
+    }
+  }
+}
+
+// ScrapeRedoSize collects the bytes of Redo generated from the v$sysstat view
+func (e *Exporter) ScrapeRedoSize(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT value FROM v$sysstat WHERE name = 'redo size'`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var value float64
+      if err := rows.Scan(&value); err != nil {
+        break
+      }
+      ch <- prometheus.MustNewConstMetric(e.redoSize, prometheus.CounterValue, value, config.Database, config.Instance)
+    }
+  }
+}
+
+// ScrapeBlocking collects the number of blocked sessions, the longest wait and the depth of the blocking trees
+func (e *Exporter) ScrapeBlocking() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT (SELECT count(*) FROM v$session WHERE blocking_session IS NOT NULL),
+                                 (SELECT nvl(max(seconds_in_wait),0) FROM v$session WHERE blocking_session IS NOT NULL),
+                                 (SELECT nvl(max(level),1)-1 FROM v$session
+                                  START WITH blocking_session IS NULL
+                                         AND sid IN (SELECT blocking_session FROM v$session
+                                                     WHERE blocking_instance = userenv('instance'))
+                                  CONNECT BY NOCYCLE PRIOR sid = blocking_session
+                                             AND blocking_instance = userenv('instance'))
+                               FROM dual`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var blocked, wait, depth float64
+      if err := rows.Scan(&blocked, &wait, &depth); err != nil {
+        break
+      }
+      e.blocking.WithLabelValues(config.Database,config.Instance,"blocked_sessions").Set(blocked)
+      e.blocking.WithLabelValues(config.Database,config.Instance,"max_wait_seconds").Set(wait)
+      e.blocking.WithLabelValues(config.Database,config.Instance,"max_depth").Set(depth)
+    }
+  }
+}
+
+// ScrapeBlockers collects the top blocking sessions
+func (e *Exporter) ScrapeBlockers() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  top := config.BlockersTop
+  if top <= 0 {
+    top = defaultBlockersTop
+  }
+
+  if db != nil {
+    rows, err = db.Query(fmt.Sprintf(`SELECT * FROM (
+                                 SELECT b.sid, nvl(b.username,'SYSTEM'), nvl(b.program,'-'),
+                                        nvl(b.sql_id,b.prev_sql_id), count(*)
+                                 FROM v$session w, v$session b
+                                 WHERE w.blocking_session = b.sid
+                                   AND w.blocking_instance = userenv('instance')
+                                 GROUP BY b.sid, b.username, b.program, nvl(b.sql_id,b.prev_sql_id)
+                                 ORDER BY count(*) DESC)
+                               WHERE rownum <= %d`, top))
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var sid string
+      var user string
+      var program string
+      var sqlID sql.NullString
+      var value float64
+      if err := rows.Scan(&sid, &user, &program, &sqlID, &value); err != nil {
+        break
+      }
+      e.blocker.WithLabelValues(config.Database,config.Instance,sid,user,program,sqlID.String).Set(value)
+    }
+  }
+}
+
+// ScrapeLockWaits collects the sessions waiting for a lock from the v$lock view.
+func (e *Exporter) ScrapeLockWaits() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT type, count(*) FROM v$lock WHERE request > 0 GROUP BY type`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var name string
+      var value float64
+      if err := rows.Scan(&name, &value); err != nil {
+        break
+      }
+      e.lockWaits.WithLabelValues(config.Database,config.Instance,name).Set(value)
+    }
+  }
+}
+
+// ScrapeSga collects the sizes of the SGA from the v$sgainfo and v$sgastat views.
+func (e *Exporter) ScrapeSga() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT name, bytes FROM v$sgainfo
+                               UNION ALL
+                               SELECT 'Shared Pool Free Memory', bytes FROM v$sgastat
+                               WHERE pool = 'shared pool' AND name = 'free memory'`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var name string
+      var value float64
+      if err := rows.Scan(&name, &value); err != nil {
+        break
+      }
+      name = cleanName(name)
+      e.sga.WithLabelValues(config.Database,config.Instance,name).Set(value)
+    }
+  }
+}
+
+// ScrapeSgaComponents collects the sizes of the dynamic SGA components from the v$sga_dynamic_components view.
+func (e *Exporter) ScrapeSgaComponents() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT component, current_size, min_size, max_size FROM v$sga_dynamic_components`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var component string
+      var current, min, max float64
+      if err := rows.Scan(&component, &current, &min, &max); err != nil {
+        break
+      }
+      component = cleanName(component)
+      e.sgaComponent.WithLabelValues(config.Database,config.Instance,"current",component).Set(current)
+      e.sgaComponent.WithLabelValues(config.Database,config.Instance,"min",component).Set(min)
+      e.sgaComponent.WithLabelValues(config.Database,config.Instance,"max",component).Set(max)
+    }
+  }
+}
+
+// ScrapeSgaResizeOps counts the SGA resize operations finished since the last scrape.
+func (e *Exporter) ScrapeSgaResizeOps() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  // v$sga_resize_ops only keeps the last 800 operations, so only the operations
+  // finished since the last scrape are added to the counters. end_time has a
+  // resolution of one second, so the operations of the last second are read
+  // again and skipped if they were already counted. The first scrape only takes
+  // the watermark, the operations before the exporter started are not counted.
+  first := e.sgaResizeLast == ""
+  since := e.sgaResizeLast
+  if first {
+    since = "19700101000000"
+  }
+
+  if db != nil {
+    rows, err = db.Query(`SELECT component, oper_type, to_char(end_time,'YYYYMMDDHH24MISS'),
+                                 oper_mode||'/'||parameter||'/'||to_char(start_time,'YYYYMMDDHH24MISS')
+                                   ||'/'||initial_size||'/'||target_size||'/'||final_size||'/'||status
+                               FROM v$sga_resize_ops
+                               WHERE end_time >= to_date('` + since + `','YYYYMMDDHH24MISS')
+                               ORDER BY end_time`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    last := e.sgaResizeLast
+    seen := e.sgaResizeSeen
+    for rows.Next() {
+      var component string
+      var operation string
+      var end string
+      var details string
+      if err := rows.Scan(&component, &operation, &end, &details); err != nil {
+        break
+      }
+      key := component + "/" + operation + "/" + end + "/" + details
+      if end == e.sgaResizeLast && e.sgaResizeSeen[key] {
+        continue
+      }
+      if end != last {
+        last, seen = end, map[string]bool{}
+      } else if seen == nil {
+        seen = map[string]bool{}
+      }
+      seen[key] = true
+      if first {
+        continue
+      }
+      component = cleanName(component)
+      operation = cleanName(operation)
+      e.sgaResize.WithLabelValues(config.Database,config.Instance,component,operation).Inc()
+    }
+    if last == "" {
+      last = since
+    }
+    e.sgaResizeLast, e.sgaResizeSeen = last, seen
+  }
+}
+
+// ScrapePgastat collects the PGA usage from the v$pgastat view.
+func (e *Exporter) ScrapePgastat(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT name, value FROM v$pgastat
+                               WHERE name in ('aggregate PGA target parameter','total PGA allocated',
+                                              'total PGA inuse','over allocation count')`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var name string
+      var value float64
+      if err := rows.Scan(&name, &value); err != nil {
+        break
+      }
+      switch name {
+      case "aggregate PGA target parameter":
+        e.pga.WithLabelValues(config.Database,config.Instance,"aggregate_target").Set(value)
+      case "total PGA allocated":
+        e.pga.WithLabelValues(config.Database,config.Instance,"allocated").Set(value)
+      case "total PGA inuse":
+        e.pga.WithLabelValues(config.Database,config.Instance,"inuse").Set(value)
+      case "over allocation count":
+        ch <- prometheus.MustNewConstMetric(e.pgaOverAlloc, prometheus.CounterValue, value, config.Database, config.Instance)
+      }
+    }
+  }
+}
+
+// ScrapeOsstat collects CPU, load, memory and paging of the host from the v$osstat view.
+func (e *Exporter) ScrapeOsstat(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT stat_name, value FROM v$osstat
+                               WHERE stat_name in ('NUM_CPUS','LOAD','PHYSICAL_MEMORY_BYTES',
+                                                   'BUSY_TIME','IDLE_TIME','IOWAIT_TIME','USER_TIME','SYS_TIME',
+                                                   'VM_IN_BYTES','VM_OUT_BYTES')`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var name string
+      var value float64
+      if err := rows.Scan(&name, &value); err != nil {
+        break
+      }
+      name = cleanName(name)
+      switch {
+      case strings.HasSuffix(name, "_time"):
+        // The times are in hundredths of a second.
+        ch <- prometheus.MustNewConstMetric(e.osstatTime, prometheus.CounterValue, value/100, config.Database, config.Instance, strings.TrimSuffix(name, "_time"))
+      case strings.HasPrefix(name, "vm_"):
+        ch <- prometheus.MustNewConstMetric(e.osstatVM, prometheus.CounterValue, value, config.Database, config.Instance, strings.TrimSuffix(strings.TrimPrefix(name, "vm_"), "_bytes"))
+      default:
+        e.osstat.WithLabelValues(config.Database,config.Instance,name).Set(value)
+      }
+    }
+  }
+}
+
+// ScrapeUndostat collects the undo statistics of the current interval and the undo errors
+// from the v$undostat view.
+func (e *Exporter) ScrapeUndostat() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  // v$undostat keeps a row per 10 minute interval, the errors of the current
+  // interval still grow. So the intervals since the last scrape are read again
+  // and only what was not counted yet is added to the counters. The first scrape
+  // only takes the counts of the current interval, the errors before the
+  // exporter started are not counted.
+  first := e.undoLast == ""
+  since := e.undoLast
+  if first {
+    since = "19700101000000"
+  }
+
+  if db != nil {
+    rows, err = db.Query(`SELECT to_char(begin_time,'YYYYMMDDHH24MISS'), maxquerylen, tuned_undoretention,
+                                 ssolderrcnt, nospaceerrcnt
+                               FROM v$undostat
+                               WHERE begin_time >= to_date('` + since + `','YYYYMMDDHH24MISS')
+                               ORDER BY begin_time`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    last := e.undoLast
+    counted := e.undoLastErrors
+    for rows.Next() {
+      var begin string
+      var maxquerylen, retention, snapshot, nospace float64
+      if err := rows.Scan(&begin, &maxquerylen, &retention, &snapshot, &nospace); err != nil {
+        break
+      }
+      counts := map[string]float64{"ssolderrcnt": snapshot, "nospaceerrcnt": nospace}
+      for name, value := range counts {
+        if first {
+          value = 0
+        } else if begin == e.undoLast {
+          value -= e.undoLastErrors[name]
+        }
+        counter := e.undoErrors.WithLabelValues(config.Database,config.Instance,name)
+        if value > 0 {
+          counter.Add(value)
+        }
+      }
+      // the rows are ordered, the last one is the current interval
+      e.undostat.WithLabelValues(config.Database,config.Instance,"maxquerylen").Set(maxquerylen)
+      e.undostat.WithLabelValues(config.Database,config.Instance,"tuned_undoretention").Set(retention)
+      last, counted = begin, counts
+    }
+    e.undoLast, e.undoLastErrors = last, counted
+  }
+}
+
+// ScrapeTempUsage collects the temp space in use per user and segment type from the v$tempseg_usage view.
+func (e *Exporter) ScrapeTempUsage() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT nvl(u.username,'-'), u.segtype, sum(u.blocks*t.block_size)
+                               FROM v$tempseg_usage u, dba_tablespaces t
+                               WHERE u.tablespace = t.tablespace_name
+                               GROUP BY u.username, u.segtype`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var user string
+      var segtype string
+      var value float64
+      if err := rows.Scan(&user, &segtype, &value); err != nil {
+        break
+      }
+      e.tempUsage.WithLabelValues(config.Database,config.Instance,user,segtype).Set(value)
+    }
+  }
+}
+
+// ScrapeSqlstats collects the statistics of the top and the tracked SQL statements from the v$sqlstats view.
+func (e *Exporter) ScrapeSqlstats(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+  top := config.TopSQL
+
+  if db == nil || (top.Top <= 0 && len(top.SqlIds) == 0) {
+    return
+  }
+
+  order := topSQLColumns[top.OrderBy]
+  if order == "" {
+    order = topSQLColumns["elapsed_time"]
+  }
+  tracked := "NULL"
+  if len(top.SqlIds) > 0 {
+    tracked = sqlList(top.SqlIds)
+  }
+  limit := top.Limit
+  if limit <= 0 {
+    limit = defaultTopSQLLimit
+  }
+  length := top.TextLength
+  if length > maxTopSQLTextLength {
+    length = maxTopSQLTextLength
+  }
+
+  // The tracked statements come first, so they are never cut off by the limit.
+  rows, err = db.Query(fmt.Sprintf(`SELECT sql_id, elapsed_time, cpu_time, executions, buffer_gets,
+                                           disk_reads, rows_processed, sql_text FROM (
+                                      SELECT sql_id, sum(elapsed_time) elapsed_time, sum(cpu_time) cpu_time,
+                                             sum(executions) executions, sum(buffer_gets) buffer_gets,
+                                             sum(disk_reads) disk_reads, sum(rows_processed) rows_processed,
+                                             substr(max(sql_text),1,%d) sql_text,
+                                             row_number() over (order by %s desc) rnk,
+                                             CASE WHEN sql_id in (%s) THEN 1 ELSE 0 END tracked
+                                      FROM v$sqlstats
+                                      GROUP BY sql_id
+                                      ORDER BY tracked DESC, rnk)
+                                    WHERE (rnk <= %d OR tracked = 1) AND rownum <= %d`,
+                                    length, order, tracked, top.Top, limit))
+  if err != nil {
+    return
+  }
+  defer rows.Close()
+  for rows.Next() {
+    var sqlID string
+    var elapsed, cpu, executions, gets, reads, processed float64
+    var text sql.NullString
+    if err := rows.Scan(&sqlID, &elapsed, &cpu, &executions, &gets, &reads, &processed, &text); err != nil {
+      break
+    }
+    label := strings.Join(strings.Fields(text.String), " ")
+    if length <= 0 {
+      label = ""
+    }
+    ch <- prometheus.MustNewConstMetric(e.sqlstatsTime, prometheus.CounterValue, elapsed/1e6, config.Database, config.Instance, "elapsed", sqlID, label)
+    ch <- prometheus.MustNewConstMetric(e.sqlstatsTime, prometheus.CounterValue, cpu/1e6, config.Database, config.Instance, "cpu", sqlID, label)
+    ch <- prometheus.MustNewConstMetric(e.sqlstats, prometheus.CounterValue, executions, config.Database, config.Instance, "executions", sqlID, label)
+    ch <- prometheus.MustNewConstMetric(e.sqlstats, prometheus.CounterValue, gets, config.Database, config.Instance, "buffer_gets", sqlID, label)
+    ch <- prometheus.MustNewConstMetric(e.sqlstats, prometheus.CounterValue, reads, config.Database, config.Instance, "disk_reads", sqlID, label)
+    ch <- prometheus.MustNewConstMetric(e.sqlstats, prometheus.CounterValue, processed, config.Database, config.Instance, "rows_processed", sqlID, label)
+  }
+}
+
+// ScrapeSqlPlans collects the current plans of the tracked SQL statements from the v$sql view.
+func (e *Exporter) ScrapeSqlPlans() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db == nil || len(config.PlanSqlIds) == 0 {
+    return
+  }
+
+  rows, err = db.Query(`SELECT sql_id, plan_hash_value, sum(elapsed_time)/nullif(sum(executions),0)
+                             FROM v$sql
+                             WHERE sql_id in (` + sqlList(config.PlanSqlIds) + `)
+                             GROUP BY sql_id, plan_hash_value`)
+  if err != nil {
+    return
+  }
+  defer rows.Close()
+
+  plans := map[string]map[string]bool{}
+  for rows.Next() {
+    var sqlID string
+    var plan string
+    var elapsed sql.NullFloat64
+    if err := rows.Scan(&sqlID, &plan, &elapsed); err != nil {
+      return
+    }
+    if plans[sqlID] == nil {
+      plans[sqlID] = map[string]bool{}
+    }
+    plans[sqlID][plan] = true
+    if elapsed.Valid {
+      // elapsed_time is in microseconds.
+      e.sqlPlan.WithLabelValues(config.Database,config.Instance,sqlID,plan).Set(elapsed.Float64/1e6)
+    }
+  }
+
+  // Statements aged out of the shared pool keep their last plans.
+  if e.sqlPlans == nil {
+    e.sqlPlans = map[string]map[string]bool{}
+  }
+  for sqlID, current := range plans {
+    changes := e.sqlPlanChange.WithLabelValues(config.Database,config.Instance,sqlID)
+    if last, ok := e.sqlPlans[sqlID]; ok {
+      for plan := range current {
+        if !last[plan] {
+          changes.Inc()
+        }
+      }
+    }
+    e.sqlPlans[sqlID] = current
+  }
+}
+
+// ScrapeFilestat collects I/O counters per datafile and tempfile from the v$filestat and v$tempstat views.
+func (e *Exporter) ScrapeFilestat(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  // In a CDB the tablespace numbers restart in every container, con_id only
+  // exists from 12c.
+  filestatQuery := func(join string) string {
+    query := `SELECT 'datafile' filetype, d.name, t.name tablespace, f.phyrds, f.phywrts,
+                     f.phyblkrd, f.phyblkwrt, f.readtim, f.writetim
+              FROM v$filestat f, v$datafile d, v$tablespace t
+              WHERE f.file# = d.file# AND d.ts# = t.ts#` + join + `
+              UNION ALL
+              SELECT 'tempfile', d.name, t.name, f.phyrds, f.phywrts,
+                     f.phyblkrd, f.phyblkwrt, f.readtim, f.writetim
+              FROM v$tempstat f, v$tempfile d, v$tablespace t
+              WHERE f.file# = d.file# AND d.ts# = t.ts#` + join
+    if config.FilestatByTablespace {
+      query = `SELECT filetype, NULL, tablespace, sum(phyrds), sum(phywrts),
+                      sum(phyblkrd), sum(phyblkwrt), sum(readtim), sum(writetim)
+               FROM (` + query + `)
+               GROUP BY filetype, tablespace`
+    }
+    return query
+  }
+
+  if db != nil {
+    rows, err = db.Query(filestatQuery(" AND d.con_id = t.con_id"))
+    if err != nil {
+      rows, err = db.Query(filestatQuery(""))
+      if err != nil {
+        return
+      }
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var filetype string
+      var file sql.NullString
+      var tablespace string
+      var phyrds, phywrts, phyblkrd, phyblkwrt float64
+      var readtim, writetim float64
+      if err := rows.Scan(&filetype, &file, &tablespace, &phyrds, &phywrts, &phyblkrd, &phyblkwrt, &readtim, &writetim); err != nil {
+        break
+      }
+      ch <- prometheus.MustNewConstMetric(e.filestat, prometheus.CounterValue, phyrds, config.Database, config.Instance, "physical_reads", filetype, file.String, tablespace)
+      ch <- prometheus.MustNewConstMetric(e.filestat, prometheus.CounterValue, phywrts, config.Database, config.Instance, "physical_writes", filetype, file.String, tablespace)
+      ch <- prometheus.MustNewConstMetric(e.filestat, prometheus.CounterValue, phyblkrd, config.Database, config.Instance, "physical_blocks_read", filetype, file.String, tablespace)
+      ch <- prometheus.MustNewConstMetric(e.filestat, prometheus.CounterValue, phyblkwrt, config.Database, config.Instance, "physical_blocks_written", filetype, file.String, tablespace)
+      // readtim and writetim are in hundredths of a second.
+      ch <- prometheus.MustNewConstMetric(e.filestatTime, prometheus.CounterValue, readtim/100, config.Database, config.Instance, "read", filetype, file.String, tablespace)
+      ch <- prometheus.MustNewConstMetric(e.filestatTime, prometheus.CounterValue, writetim/100, config.Database, config.Instance, "write", filetype, file.String, tablespace)
+    }
+  }
+}
+
+// ScrapeTablespaces collects tablespace metrics
+func (e *Exporter) ScrapeTablespace() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`WITH
+                                 getsize AS (SELECT tablespace_name, max(autoextensible) autoextend, SUM(bytes) tsize,
+                                                    SUM(decode(autoextensible,'YES',greatest(bytes,maxbytes),bytes)) tmax
+                                             FROM dba_data_files GROUP BY tablespace_name),
+                                 getfree as (SELECT tablespace_name, SUM(bytes) tfree
+                                             FROM dba_free_space GROUP BY tablespace_name),
+                                 gettemp as (SELECT tablespace_name, max(autoextensible) autoextend,
+                                                    SUM(decode(autoextensible,'YES',greatest(bytes,maxbytes),bytes)) tmax
+                                             FROM dba_temp_files GROUP BY tablespace_name)
+                               SELECT a.tablespace_name, c.contents, a.tsize, nvl(b.tfree,0), a.tmax, a.autoextend
+                               FROM GETSIZE a, GETFREE b, dba_tablespaces c
+                               WHERE a.tablespace_name = b.tablespace_name(+)
+                                 AND a.tablespace_name = c.tablespace_name
+                               UNION ALL
+                               SELECT f.tablespace_name, 'TEMPORARY', f.tablespace_size, f.free_space, t.tmax, t.autoextend
+                               FROM dba_temp_free_space f, gettemp t
+                               WHERE f.tablespace_name = t.tablespace_name`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+
+    // Sums of the excluded tablespaces per contents and autoextend.
+    type space struct{ tsize, tfree, tmax float64 }
+    other := map[[2]string]*space{}
+
+    set := func(name, contents, auto string, tsize, tfree, tmax float64) {
+      e.tablespace.WithLabelValues(config.Database,config.Instance,"total",name,contents,auto).Set(tsize)
+      e.tablespace.WithLabelValues(config.Database,config.Instance,"free",name,contents,auto).Set(tfree)
+      e.tablespace.WithLabelValues(config.Database,config.Instance,"used",name,contents,auto).Set(tsize-tfree)
+      e.tablespace.WithLabelValues(config.Database,config.Instance,"max",name,contents,auto).Set(tmax)
+      if tmax > 0 {
+        e.tablespace.WithLabelValues(config.Database,config.Instance,"used_percent_of_max",name,contents,auto).Set((tsize-tfree)/tmax*100)
+      }
+    }
+
+    for rows.Next() {
+      var name string
+      var contents string
+      var tsize float64
+      var tfree float64
+      var tmax float64
+      var auto string
+      if err := rows.Scan(&name, &contents, &tsize, &tfree, &tmax, &auto); err != nil {
+        break
+      }
+      // Never report a max size below the current size.
+      if tmax < tsize {
+        tmax = tsize
+      }
+      if !config.Tablespaces.Keep(name) {
+        if config.Tablespaces.Other {
+          key := [2]string{contents, auto}
+          if other[key] == nil {
+            other[key] = &space{}
+          }
+          other[key].tsize += tsize
+          other[key].tfree += tfree
+          other[key].tmax += tmax
+        }
+        continue
+      }
+      set(name, contents, auto, tsize, tfree, tmax)
+    }
+
+    for key, sum := range other {
+      set("other", key[0], key[1], sum.tsize, sum.tfree, sum.tmax)
+    }
+  }
+}
+
+// ScrapeSessions collects session metrics from the v$session view.
+func (e *Exporter) ScrapeSession() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT decode(username,NULL,'SYSTEM','SYS','SYSTEM','USER'), status,count(*)
+                               FROM v$session
+                               GROUP BY decode(username,NULL,'SYSTEM','SYS','SYSTEM','USER'),status`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var user string
+      var status string
+      var value float64
+      if err := rows.Scan(&user, &status, &value); err != nil {
+        break
+      }
+      e.session.WithLabelValues(config.Database,config.Instance,user,status).Set(value)
+    }
+  }
+}
+
+
+// sessionGroup returns the gauge for the session dimensions configured on the connection.
+func (e *Exporter) sessionGroup() *prometheus.GaugeVec {
+  if e.sessiongroup == nil {
+    e.sessiongroup = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+      Namespace: namespace,
+      Name:      "session_group",
+      Help:      "Gauge metric with sessions grouped by the configured session_groups, the long tail is summed up as other (v$session).",
+    }, append([]string{"database","dbinstance"}, e.config.SessionGroups...))
+  }
+  return e.sessiongroup
+}
+
+// ScrapeSessionGroup collects the sessions grouped by the dimensions configured on the connection.
+func (e *Exporter) ScrapeSessionGroup() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db == nil || len(config.SessionGroups) == 0 {
+    return
+  }
+
+  limit := config.SessionGroupsLimit
+  if limit <= 0 {
+    limit = defaultSessionGroupsLimit
+  }
+
+  columns := make([]string, len(config.SessionGroups))
+  for i, dim := range config.SessionGroups {
+    columns[i] = sessionColumns[dim]
+  }
+  group := strings.Join(columns, ",")
+
+  rows, err = db.Query(`SELECT ` + group + `, count(*) FROM v$session
+                             GROUP BY ` + group + `
+                             ORDER BY count(*) DESC`)
+  if err != nil {
+    return
+  }
+  defer rows.Close()
+
+  sessions := e.sessionGroup()
+  other := make([]string, len(columns))
+  for i := range other {
+    other[i] = "other"
+  }
+
+  n := 0
+  for rows.Next() {
+    labels := make([]string, len(columns))
+    dest := make([]interface{}, len(columns)+1)
+    for i := range labels {
+      dest[i] = &labels[i]
+    }
+    var value float64
+    dest[len(columns)] = &value
+    if err := rows.Scan(dest...); err != nil {
+      break
+    }
+    n++
+    // Keep one series free for the long tail.
+    if n >= limit {
+      labels = other
+    }
+    sessions.WithLabelValues(append([]string{config.Database,config.Instance}, labels...)...).Add(value)
+  }
+}
+
+
+// ScrapeUptime Instance uptime
+func (e *Exporter) ScrapeUptime() {
+  var uptime float64
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err := db.Query("select sysdate-startup_time from v$instance")
+    if err != nil {
+      return
+    }
+
+    defer rows.Close()
+    rows.Next()
+    err = rows.Scan(&uptime)
+    if err == nil {
+      e.uptime.WithLabelValues(config.Database,config.Instance).Set(uptime)
+    }
+  }
+}
+
+// ScrapeSysstat collects activity metrics from the v$sysstat view.
+func (e *Exporter) ScrapeSysstat(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  // The statistic# differs between the releases, so the statistics are selected by name.
+  names := config.Sysstat
+  if len(names) == 0 {
+    names = defaultSysstat
+  }
+
+  if db != nil {
+    rows, err = db.Query(`SELECT name, value FROM v$sysstat
+                                    WHERE name in (` + sqlList(names) + `)`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var name string
+      var value float64
+      if err := rows.Scan(&name, &value); err != nil {
+        break
+      }
+      name = cleanName(name)
+      ch <- prometheus.MustNewConstMetric(e.sysstat, prometheus.CounterValue, value, config.Database, config.Instance, name)
+    }
+  }
+}
+
+// ScrapeTimeModel collects the time statistics (DB time, DB CPU, ...) from the v$sys_time_model view.
+func (e *Exporter) ScrapeTimeModel(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT stat_name, value FROM v$sys_time_model`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var name string
+      var value float64
+      if err := rows.Scan(&name, &value); err != nil {
+        break
+      }
+      name = cleanName(name)
+      // The time statistics are in microseconds.
+      ch <- prometheus.MustNewConstMetric(e.timeModel, prometheus.CounterValue, value/1e6, config.Database, config.Instance, name)
+    }
+  }
+}
+
+// ScrapeServiceStats collects the time statistics per service from the v$service_stats view.
+func (e *Exporter) ScrapeServiceStats(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil && config.ServiceStats {
+    rows, err = db.Query(`SELECT stat_name, service_name, value FROM v$service_stats
+                               WHERE stat_name in ('DB time','DB CPU')`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var name string
+      var service string
+      var value float64
+      if err := rows.Scan(&name, &service, &value); err != nil {
+        break
+      }
+      name = cleanName(name)
+      ch <- prometheus.MustNewConstMetric(e.serviceStats, prometheus.CounterValue, value/1e6, config.Database, config.Instance, name, service)
+    }
+  }
+}
+
+// ScrapeWaitTime collects wait time metrics from the v$waitclassmetric view.
+func (e *Exporter) ScrapeWaitclass() {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  if db != nil {
+    rows, err = db.Query(`SELECT n.wait_class, round(m.time_waited/m.INTSIZE_CSEC,3)
+                                  FROM v$waitclassmetric  m, v$system_wait_class n
+                                  WHERE m.wait_class_id=n.wait_class_id and n.wait_class != 'Idle'`)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var name string
+      var value float64
+      if err := rows.Scan(&name, &value); err != nil {
+        break
+      }
+      name = cleanName(name)
+      e.waitclass.WithLabelValues(config.Database,config.Instance,name).Set(value)
+    }
+  }
+}
+
+// ScrapeSystemEvent collects the waits per wait event from the v$system_event view.
+func (e *Exporter) ScrapeSystemEvent(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  query := `SELECT event, wait_class, total_waits, time_waited_micro, total_timeouts
+            FROM v$system_event
+            WHERE wait_class != 'Idle'`
+  if len(config.Events) > 0 {
+    query += ` AND event in (` + sqlList(config.Events) + `)`
+  }
+  if config.EventsTop > 0 {
+    query = fmt.Sprintf(`SELECT * FROM (%s ORDER BY time_waited_micro DESC) WHERE rownum <= %d`, query, config.EventsTop)
+  }
+
+  if db != nil {
+    rows, err = db.Query(query)
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+    for rows.Next() {
+      var event string
+      var class string
+      var waits, waited, timeouts float64
+      if err := rows.Scan(&event, &class, &waits, &waited, &timeouts); err != nil {
+        break
+      }
+      event = cleanName(event)
+      class = cleanName(class)
+      ch <- prometheus.MustNewConstMetric(e.eventWaits, prometheus.CounterValue, waits, config.Database, config.Instance, event, class)
+      ch <- prometheus.MustNewConstMetric(e.eventTime, prometheus.CounterValue, waited/1e6, config.Database, config.Instance, event, class)
+      ch <- prometheus.MustNewConstMetric(e.eventTimeouts, prometheus.CounterValue, timeouts, config.Database, config.Instance, event, class)
+    }
+  }
+}
+
+// ScrapeEventHistogram collects the wait time histograms of the configured events from the
+// v$event_histogram_micro view, or v$event_histogram before 12.2.
+func (e *Exporter) ScrapeEventHistogram(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  events := config.EventHistograms
+  if len(events) == 0 {
+    events = defaultEventHistograms
+  }
+
+  if db != nil {
+    // A bucket holds the waits shorter than its wait time, the sum comes from v$system_event.
+    rows, err = db.Query(`SELECT h.event, h.wait_time_micro, h.wait_count, e.time_waited_micro
+                               FROM v$event_histogram_micro h, v$system_event e
+                               WHERE h.event = e.event AND h.event in (` + sqlList(events) + `)
+                               ORDER BY h.event, h.wait_time_micro`)
+    if err != nil {
+      rows, err = db.Query(`SELECT h.event, h.wait_time_milli*1000, h.wait_count, e.time_waited_micro
+                                 FROM v$event_histogram h, v$system_event e
+                                 WHERE h.event = e.event AND h.event in (` + sqlList(events) + `)
+                                 ORDER BY h.event, h.wait_time_milli`)
+      if err != nil {
+        return
+      }
+    }
+    defer rows.Close()
+
+    var (
+      last    string
+      count   uint64
+      sum     float64
+      buckets map[float64]uint64
+    )
+    flush := func() {
+      if buckets != nil {
+        ch <- prometheus.MustNewConstHistogram(e.eventHistogram, count, sum, buckets, config.Database, config.Instance, cleanName(last))
+      }
+    }
+    for rows.Next() {
+      var event string
+      var bucket float64
+      var waits float64
+      var waited float64
+      if err := rows.Scan(&event, &bucket, &waits, &waited); err != nil {
+        break
+      }
+      if event != last {
+        flush()
+        last, count, sum, buckets = event, 0, waited/1e6, map[float64]uint64{}
+      }
+      count += uint64(waits)
+      buckets[bucket/1e6] = count
+    }
+    flush()
+  }
+}
+
+// newSysmetricDescs returns the descriptors of the configured v$sysmetric metrics by metric_name.
+func newSysmetricDescs(names []string) map[string]*prometheus.Desc {
+  if len(names) == 0 {
+    names = defaultSysmetric
+  }
+  descs := map[string]*prometheus.Desc{}
+  for _, name := range names {
+    unit := sysmetricUnits[name]
+    help := fmt.Sprintf("%s (v$sysmetric).", name)
+    if unit != "" {
+      help = fmt.Sprintf("%s in %s (v$sysmetric).", name, unit)
+    }
+    descs[name] = prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "sysmetric", sysmetricName(name, unit)),
+      help,
+      []string{"database","dbinstance"}, nil)
+  }
+  return descs
+}
+
+// ScrapeSysmetrics collects the configured metrics from the v$sysmetrics view.
+func (e *Exporter) ScrapeSysmetric(ch chan<- prometheus.Metric) {
+  var (
+    rows *sql.Rows
+    err  error
+  )
+
+  config := e.config
+  db := config.db
+
+  names := config.Sysmetric
+  if len(names) == 0 {
+    names = defaultSysmetric
+  }
+  // The metrics of oracledb_sysmetric and oracledb_cachehitratio are always read
+  // from the long duration group.
+  legacy := append(append([]string{}, legacySysmetric...), legacyCache...)
+
+  //group_id  name
+  //2         System Metrics Long Duration (60s)
+  //3         System Metrics Short Duration (15s)
+  group := 2
+  if config.SysmetricGroup == "short" {
+    group = 3
+  }
+
+  if db != nil {
+    rows, err = db.Query(fmt.Sprintf(`select group_id,metric_name,value from v$sysmetric
+                                           where (group_id=%d and metric_name in (%s))
+                                              or (group_id=2 and metric_name in (%s))`,
+                                     group, sqlList(names), sqlList(legacy)))
+    if err != nil {
+      return
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+      var groupID int
+      var name string
+      var value float64
+      if err := rows.Scan(&groupID, &name, &value); err != nil {
+        break
+      }
+      if groupID == 2 && contains(legacySysmetric, name) {
+        e.sysmetric.WithLabelValues(config.Database,config.Instance,cleanName(name)).Set(value)
+      }
+      if groupID == 2 && contains(legacyCache, name) {
+        e.cache.WithLabelValues(config.Database,config.Instance,cleanName(name)).Set(value)
+      }
+      desc := e.sysmetricDescs[name]
+      if groupID != group || desc == nil {
+        continue
+      }
+      ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, config.Database, config.Instance)
+    }
+  }
+}
+
+// Describe describes all the metrics exported by the Oracle exporter.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+  e.up.Describe(ch)
+  e.skipped.Describe(ch)
+  e.session.Describe(ch)
+  ch <- e.sysstat
+  e.duration.Describe(ch)
+  e.totalScrapes.Describe(ch)
+  e.scrapeErrors.Describe(ch)
+  e.waitclass.Describe(ch)
+  e.sysmetric.Describe(ch)
+  for _, desc := range e.sysmetricDescs {
+    ch <- desc
+  }
+  e.interconnect.Describe(ch)
+  e.tablespace.Describe(ch)
+  e.recovery.Describe(ch)
+  e.redo.Describe(ch)
+  e.cache.Describe(ch)
+  e.uptime.Describe(ch)
+  e.services.Describe(ch)
+  e.parameter.Describe(ch)
+  e.parameterInfo.Describe(ch)
+  e.parameterHash.Describe(ch)
+  e.parameterDrift.Describe(ch)
+  e.parameterChange.Describe(ch)
+  e.query.Describe(ch)
+  e.asmspace.Describe(ch)
+  e.asmdiskgroup.Describe(ch)
+  e.asmdisk.Describe(ch)
+  ch <- e.asmdiskErrors
+  e.asmoperation.Describe(ch)
+  e.dataguard.Describe(ch)
+  e.dataguardInfo.Describe(ch)
+  e.archivedest.Describe(ch)
+  e.archivedestErr.Describe(ch)
+  e.mrp.Describe(ch)
+  e.backup.Describe(ch)
+  e.bct.Describe(ch)
+  e.archivedLogs.Describe(ch)
+  e.archivedBytes.Describe(ch)
+  e.archivedestInfo.Describe(ch)
+  e.archivedestFail.Describe(ch)
+  e.recoveryDest.Describe(ch)
+  e.redoGroup.Describe(ch)
+  e.redoGroupStatus.Describe(ch)
+  e.redoWaiting.Describe(ch)
+  ch <- e.redoSwitches
+  ch <- e.redoSize
+  e.blocking.Describe(ch)
+  e.blocker.Describe(ch)
+  e.lockWaits.Describe(ch)
+  e.sessionGroup().Describe(ch)
+  ch <- e.timeModel
+  ch <- e.serviceStats
+  ch <- e.eventWaits
+  ch <- e.eventTime
+  ch <- e.eventTimeouts
+  ch <- e.eventHistogram
+  e.sga.Describe(ch)
+  e.sgaComponent.Describe(ch)
+  e.sgaResize.Describe(ch)
+  e.pga.Describe(ch)
+  ch <- e.pgaOverAlloc
+  e.osstat.Describe(ch)
+  ch <- e.osstatTime
+  ch <- e.osstatVM
+  e.undostat.Describe(ch)
+  e.undoErrors.Describe(ch)
+  e.tempUsage.Describe(ch)
+  ch <- e.sqlstats
+  ch <- e.sqlstatsTime
+  e.sqlPlan.Describe(ch)
+  e.sqlPlanChange.Describe(ch)
+  e.resourceLimit.Describe(ch)
+  ch <- e.filestat
+  ch <- e.filestatTime
+}
+
+// Connect the DBs and gather Databasename and Instancename
+func (e *Exporter) Connect() {
+  e.up.Reset()
+  e.skipped.Reset()
+  e.session.Reset()
+  e.waitclass.Reset()
+  e.sysmetric.Reset()
+  e.interconnect.Reset()
+  e.tablespace.Reset()
+  e.recovery.Reset()
+  e.redo.Reset()
+  e.cache.Reset()
+  e.uptime.Reset()
+  e.services.Reset()
+  e.parameter.Reset()
+  e.parameterInfo.Reset()
+  e.parameterHash.Reset()
+  e.parameterDrift.Reset()
+  e.query.Reset()
+  e.asmspace.Reset()
+  e.asmdiskgroup.Reset()
+  e.asmdisk.Reset()
+  e.asmoperation.Reset()
+  e.dataguard.Reset()
+  e.dataguardInfo.Reset()
+  e.archivedest.Reset()
+  e.archivedestErr.Reset()
+  e.mrp.Reset()
+  e.backup.Reset()
+  e.bct.Reset()
+  e.archivedestInfo.Reset()
+  e.archivedestFail.Reset()
+  e.recoveryDest.Reset()
+  e.redoGroup.Reset()
+  e.redoGroupStatus.Reset()
+  e.redoWaiting.Reset()
+  e.blocking.Reset()
+  e.blocker.Reset()
+  e.lockWaits.Reset()
+  e.sessionGroup().Reset()
+  e.resourceLimit.Reset()
+  e.sga.Reset()
+  e.sgaComponent.Reset()
+  e.pga.Reset()
+  e.osstat.Reset()
+  e.undostat.Reset()
+  e.tempUsage.Reset()
+  e.sqlPlan.Reset()
+
+  config := &e.config
+
+  dsn := fmt.Sprintf("%s/%s@%s", config.User, config.Password, config.Connection)
+  db , err := sql.Open("ora", dsn)
+  config.db = db
+
+  if err != nil {
+    log.Infoln(err)
+    e.up.WithLabelValues(config.Database,config.Instance).Set(0)
+
+    if db != nil {
+      db.Close()
       config.db = nil
     }
 
     return
   }
 
-  rows, err := db.Query("select db_unique_name,instance_name from v$database,v$instance")
+  rows, err := db.Query("select db_unique_name,instance_name,open_mode,database_role from v$database,v$instance")
   if err != nil {
     log.Infoln(err)
     db.Close()
@@ -672,7 +2801,7 @@ func (e *Exporter) Connect() {
 
   defer rows.Close()
   rows.Next()
-  err = rows.Scan(&config.Database,&config.Instance)
+  err = rows.Scan(&config.Database,&config.Instance,&config.openMode,&config.role)
 
   if err == nil {
     e.up.WithLabelValues(config.Database, config.Instance).Set(1)
@@ -684,6 +2813,23 @@ func (e *Exporter) Connect() {
   }
 }
 
+// runnable reports whether the collector is safe for the open mode of the database.
+// Skipped collectors are exported with the role and open mode as reason.
+func (e *Exporter) runnable(collector string) bool {
+  config := e.config
+
+  states, ok := collectorStates[collector]
+  if !ok {
+    states = stateReadWrite
+  }
+  if config.db == nil || states&config.state() != 0 {
+    return true
+  }
+  reason := cleanName(config.role + " " + config.openMode)
+  e.skipped.WithLabelValues(config.Database,config.Instance,collector,reason).Set(1)
+  return false
+}
+
 // Close Connections
 func (e *Exporter) Close() {
   if e.config.db != nil {
@@ -697,6 +2843,9 @@ func (e *Exporter) Close() {
 func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
   var err error
 
+  e.mu.Lock()
+  defer e.mu.Unlock()
+
   defer func(begun time.Time) {
     e.duration.WithLabelValues(e.config.Database,e.config.Instance).Set(time.Since(begun).Seconds())
     if err == nil {
@@ -712,52 +2861,249 @@ func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
 
   e.up.Collect(ch)
 
-  e.ScrapeUptime()
+  if e.runnable("uptime") {
+    e.ScrapeUptime()
+  }
   e.uptime.Collect(ch)
 
-  e.ScrapeSession()
+  if e.runnable("session") {
+    e.ScrapeSession()
+  }
   e.session.Collect(ch)
 
-  e.ScrapeSysstat()
-  e.sysstat.Collect(ch)
+  if e.runnable("session_group") {
+    e.ScrapeSessionGroup()
+  }
+  e.sessionGroup().Collect(ch)
+
+  if e.runnable("sysstat") {
+    e.ScrapeSysstat(ch)
+  }
+
+  if e.runnable("time_model") {
+    e.ScrapeTimeModel(ch)
+  }
+
+  if e.runnable("service_stats") {
+    e.ScrapeServiceStats(ch)
+  }
 
-  e.ScrapeWaitclass()
+  if e.runnable("waitclass") {
+    e.ScrapeWaitclass()
+  }
   e.waitclass.Collect(ch)
 
-  e.ScrapeSysmetric()
+  if e.runnable("system_event") {
+    e.ScrapeSystemEvent(ch)
+  }
+
+  if e.runnable("event_histogram") {
+    e.ScrapeEventHistogram(ch)
+  }
+
+  if e.runnable("sysmetric") {
+    e.ScrapeSysmetric(ch)
+  }
   e.sysmetric.Collect(ch)
+  e.cache.Collect(ch)
 
-  e.ScrapeTablespace()
+  if e.runnable("tablespace") {
+    e.ScrapeTablespace()
+  }
   e.tablespace.Collect(ch)
 
-  e.ScrapeInterconnect()
+  if e.runnable("interconnect") {
+    e.ScrapeInterconnect()
+  }
   e.interconnect.Collect(ch)
 
-  e.ScrapeRecovery()
+  if e.runnable("recovery") {
+    e.ScrapeRecovery()
+  }
   e.recovery.Collect(ch)
 
-  e.ScrapeRedo()
+  if e.runnable("redo") {
+    e.ScrapeRedo()
+  }
   e.redo.Collect(ch)
 
-  e.ScrapeCache()
-  e.cache.Collect(ch)
-
-  e.ScrapeServices()
+  if e.runnable("services") {
+    e.ScrapeServices()
+  }
   e.services.Collect(ch)
 
-  e.ScrapeParameter()
+  if e.runnable("parameter") {
+    e.ScrapeParameter()
+  }
   e.parameter.Collect(ch)
+  e.parameterInfo.Collect(ch)
+
+  if e.runnable("parameter_drift") {
+    e.ScrapeParameterDrift()
+  }
+  e.parameterHash.Collect(ch)
+  e.parameterDrift.Collect(ch)
+  e.parameterChange.Collect(ch)
+
+  if e.runnable("resource_limit") {
+    e.ScrapeResourceLimit()
+  }
+  e.resourceLimit.Collect(ch)
 
-  e.ScrapeQuery()
+  if e.runnable("query") {
+    e.ScrapeQuery()
+  }
   e.query.Collect(ch)
 
-  e.ScrapeAsmspace()
+  if e.runnable("asmspace") {
+    e.ScrapeAsmspace()
+  }
   e.asmspace.Collect(ch)
 
+  if e.runnable("asmdiskgroup") {
+    e.ScrapeAsmdiskgroup()
+  }
+  e.asmdiskgroup.Collect(ch)
+
+  if e.runnable("asmdisk") {
+    e.ScrapeAsmdisk(ch)
+  }
+  e.asmdisk.Collect(ch)
+
+  if e.runnable("asmoperation") {
+    e.ScrapeAsmoperation()
+  }
+  e.asmoperation.Collect(ch)
+
+  if e.runnable("dataguard_info") {
+    e.ScrapeDataguardInfo()
+  }
+  e.dataguardInfo.Collect(ch)
+
+  if e.runnable("dataguard_stats") {
+    e.ScrapeDataguardStats()
+  }
+  e.dataguard.Collect(ch)
+
+  if e.runnable("archive_dest_status") {
+    e.ScrapeArchiveDestStatus()
+  }
+  e.archivedest.Collect(ch)
+  e.archivedestErr.Collect(ch)
+
+  if e.runnable("managed_standby") {
+    e.ScrapeManagedStandby()
+  }
+  e.mrp.Collect(ch)
+
+  if e.runnable("backup") {
+    e.ScrapeBackup()
+  }
+  e.backup.Collect(ch)
+
+  if e.runnable("block_change_tracking") {
+    e.ScrapeBlockChangeTracking()
+  }
+  e.bct.Collect(ch)
+
+  if e.runnable("archived_log") {
+    e.ScrapeArchivedLog()
+  }
+  e.archivedLogs.Collect(ch)
+  e.archivedBytes.Collect(ch)
+
+  if e.runnable("archive_dest") {
+    e.ScrapeArchiveDest()
+  }
+  e.archivedestInfo.Collect(ch)
+  e.archivedestFail.Collect(ch)
+
+  if e.runnable("recovery_file_dest") {
+    e.ScrapeRecoveryDest()
+  }
+  e.recoveryDest.Collect(ch)
+
+  if e.runnable("redo_log") {
+    e.ScrapeRedoLog(ch)
+  }
+  e.redoGroup.Collect(ch)
+  e.redoGroupStatus.Collect(ch)
+  e.redoWaiting.Collect(ch)
+
+  if e.runnable("redo_size") {
+    e.ScrapeRedoSize(ch)
+  }
+
+  if e.runnable("blocking") {
+    e.ScrapeBlocking()
+  }
+  e.blocking.Collect(ch)
+
+  if e.runnable("blockers") {
+    e.ScrapeBlockers()
+  }
+  e.blocker.Collect(ch)
+
+  if e.runnable("lock_waits") {
+    e.ScrapeLockWaits()
+  }
+  e.lockWaits.Collect(ch)
+
+  if e.runnable("sga") {
+    e.ScrapeSga()
+  }
+  e.sga.Collect(ch)
+
+  if e.runnable("sga_components") {
+    e.ScrapeSgaComponents()
+  }
+  e.sgaComponent.Collect(ch)
+
+  if e.runnable("sga_resize_ops") {
+    e.ScrapeSgaResizeOps()
+  }
+  e.sgaResize.Collect(ch)
+
+  if e.runnable("pgastat") {
+    e.ScrapePgastat(ch)
+  }
+  e.pga.Collect(ch)
+
+  if e.runnable("osstat") {
+    e.ScrapeOsstat(ch)
+  }
+  e.osstat.Collect(ch)
+
+  if e.runnable("undostat") {
+    e.ScrapeUndostat()
+  }
+  e.undostat.Collect(ch)
+  e.undoErrors.Collect(ch)
+
+  if e.runnable("temp_usage") {
+    e.ScrapeTempUsage()
+  }
+  e.tempUsage.Collect(ch)
+
+  if e.runnable("sqlstats") {
+    e.ScrapeSqlstats(ch)
+  }
+
+  if e.runnable("sql_plans") {
+    e.ScrapeSqlPlans()
+  }
+  e.sqlPlan.Collect(ch)
+  e.sqlPlanChange.Collect(ch)
+
+  if e.runnable("filestat") {
+    e.ScrapeFilestat(ch)
+  }
+
   e.duration.Collect(ch)
   e.totalScrapes.Collect(ch)
   e.error.Collect(ch)
   e.scrapeErrors.Collect(ch)
+  e.skipped.Collect(ch)
 }
 
 func (e *Exporter) Handler(w http.ResponseWriter, r *http.Request) {
@@ -774,6 +3120,7 @@ func ScrapeHandler(w http.ResponseWriter, r *http.Request) {
           registry := prometheus.NewRegistry()
           exporter := NewExporter()
           exporter.config = conn
+          exporter.sysmetricDescs = newSysmetricDescs(conn.Sysmetric)
           registry.MustRegister(exporter)
           handlers[target] = promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
         }
@@ -803,6 +3150,42 @@ func LoadConfig() bool {
       log.Fatalf("error: %v", err)
       return false
     }
+    for i := range configs.Cfgs {
+      conn := &configs.Cfgs[i]
+      if err := conn.Tablespaces.Compile(); err != nil {
+        log.Fatalf("error: tablespaces filter of %v: %v", conn.Connection, err)
+        return false
+      }
+      if err := conn.Diskgroups.Compile(); err != nil {
+        log.Fatalf("error: diskgroups filter of %v: %v", conn.Connection, err)
+        return false
+      }
+      if conn.SysmetricGroup != "" && conn.SysmetricGroup != "long" && conn.SysmetricGroup != "short" {
+        log.Fatalf("error: sysmetric_group of %v: must be long or short, not %v", conn.Connection, conn.SysmetricGroup)
+        return false
+      }
+      if conn.TopSQL.OrderBy != "" && topSQLColumns[conn.TopSQL.OrderBy] == "" {
+        log.Fatalf("error: topsql order_by of %v: unknown column %v", conn.Connection, conn.TopSQL.OrderBy)
+        return false
+      }
+      metrics := map[string]bool{}
+      for _, name := range conn.Sysmetric {
+        metric := sysmetricName(name, sysmetricUnits[name])
+        if metrics[metric] {
+          log.Fatalf("error: sysmetric of %v: duplicate metric name for %v", conn.Connection, name)
+          return false
+        }
+        metrics[metric] = true
+      }
+      seen := map[string]bool{}
+      for _, dim := range conn.SessionGroups {
+        if sessionColumns[dim] == "" || seen[dim] {
+          log.Fatalf("error: session_groups of %v: unknown or duplicate dimension %v", conn.Connection, dim)
+          return false
+        }
+        seen[dim] = true
+      }
+    }
     return true
   }
 }
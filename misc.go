@@ -1,7 +1,10 @@
 package main
 
 import (
+    "fmt"
+    "strconv"
     "strings"
+    "regexp"
     "database/sql"
 )
 
@@ -15,11 +18,89 @@ type Config struct {
   User string        `yaml:"user"`
   Password string    `yaml:"password"`
   Queries []Query    `yaml:"queries"`
+  FilestatByTablespace bool `yaml:"filestat_by_tablespace"`
+  Tablespaces NameFilter `yaml:"tablespaces"`
+  Diskgroups NameFilter  `yaml:"diskgroups"`
+  BlockersTop int        `yaml:"blockers_top"`
+  SessionGroups []string `yaml:"session_groups"`
+  SessionGroupsLimit int `yaml:"session_groups_limit"`
+  Parameters []string    `yaml:"parameters"`
+  Sysstat []string       `yaml:"sysstat"`
+  Sysmetric []string     `yaml:"sysmetric"`
+  SysmetricGroup string  `yaml:"sysmetric_group"`
+  ServiceStats bool      `yaml:"service_stats"`
+  Events []string        `yaml:"events"`
+  EventsTop int          `yaml:"events_top"`
+  EventHistograms []string `yaml:"event_histograms"`
+  ParameterFlags bool    `yaml:"parameter_flags"`
+  TopSQL TopSQL          `yaml:"topsql"`
+  PlanSqlIds []string    `yaml:"plan_sql_ids"`
   db                 *sql.DB
+  openMode           string
+  role               string
   Instance string
   Database string
 }
 
+// state maps the open mode found at connect time to one of the state flags.
+func (c *Config) state() int {
+  switch {
+  case c.openMode == "MOUNTED":
+    return stateMounted
+  case strings.HasPrefix(c.openMode, "READ ONLY"):
+    return stateReadOnly
+  }
+  return stateReadWrite
+}
+
+// NameFilter selects objects (tablespaces, diskgroups) by name. Include and Exclude
+// are regular expressions matched against the whole name. With Other set, the
+// excluded objects are summed up under the name "other" instead of being dropped.
+type NameFilter struct {
+  Include string     `yaml:"include"`
+  Exclude string     `yaml:"exclude"`
+  Other bool         `yaml:"other"`
+  include            *regexp.Regexp
+  exclude            *regexp.Regexp
+}
+
+// Compile the include and exclude expressions of the filter.
+func (f *NameFilter) Compile() error {
+  var err error
+  if f.Include != "" {
+    if f.include, err = regexp.Compile("^(?:" + f.Include + ")$"); err != nil {
+      return err
+    }
+  }
+  if f.Exclude != "" {
+    if f.exclude, err = regexp.Compile("^(?:" + f.Exclude + ")$"); err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+// Keep reports whether the object with the given name should be exported on its own.
+func (f *NameFilter) Keep(name string) bool {
+  if f.include != nil && !f.include.MatchString(name) {
+    return false
+  }
+  if f.exclude != nil && f.exclude.MatchString(name) {
+    return false
+  }
+  return true
+}
+
+// TopSQL selects the statements exported from v$sqlstats: the Top statements ordered
+// by OrderBy and the SqlIds always, but never more than Limit statements per scrape.
+type TopSQL struct {
+  Top int            `yaml:"top"`
+  OrderBy string     `yaml:"order_by"`
+  SqlIds []string    `yaml:"sql_ids"`
+  TextLength int     `yaml:"text_length"`
+  Limit int          `yaml:"limit"`
+}
+
 type Configs struct {
   Cfgs []Config `yaml:"connections"`
 }
@@ -39,3 +120,87 @@ func cleanIp(s string) string {
   s = strings.Replace(s, ".", "_", -1)  // Remove open parenthesis
   return s
 }
+
+// sysmetricName derives the metric name from the name and unit of a v$sysmetric metric,
+// e.g. "Buffer Cache Hit Ratio" in "% (LogRead - PhyRead)/LogRead" becomes buffer_cache_hit_ratio_percent.
+func sysmetricName(name, unit string) string {
+  suffix := metricName(unit)
+  if strings.Contains(name, "%") || strings.Contains(unit, "%") {
+    suffix = "percent"
+  }
+  name = metricName(name)
+  if suffix == "" || strings.HasSuffix(name, suffix) {
+    return name
+  }
+  return name + "_" + suffix
+}
+
+// metricName turns s into a valid metric name part. Abbreviations like "Per Sec" are written out.
+func metricName(s string) string {
+  words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+    return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+  })
+  for i := 1; i < len(words); i++ {
+    if words[i-1] == "per" && words[i] == "sec" {
+      words[i] = "second"
+    }
+  }
+  return strings.Join(words, "_")
+}
+
+// contains reports whether name is in names.
+func contains(names []string, name string) bool {
+  for _, n := range names {
+    if n == name {
+      return true
+    }
+  }
+  return false
+}
+
+// sqlList quotes the names for the use in an IN list of a query.
+func sqlList(names []string) string {
+  quoted := make([]string, len(names))
+  for i, name := range names {
+    quoted[i] = "'" + strings.Replace(name, "'", "''", -1) + "'"
+  }
+  return strings.Join(quoted, ",")
+}
+
+// Oracle reports intervals like the Data Guard lags as strings ("+00 00:00:05").
+// This function converts them to seconds.
+func parseInterval(s string) (float64, error) {
+  var days, hours, minutes int
+  var seconds float64
+
+  sign := 1.0
+  s = strings.TrimSpace(s)
+  if strings.HasPrefix(s, "-") {
+    sign = -1
+  }
+  s = strings.TrimLeft(s, "+-")
+  if _, err := fmt.Sscanf(s, "%d %d:%d:%g", &days, &hours, &minutes, &seconds); err != nil {
+    return 0, err
+  }
+  return sign * (float64(days)*86400 + float64(hours)*3600 + float64(minutes)*60 + seconds), nil
+}
+
+// Oracle parameters may carry a unit (K, M, G, T, P) like 512M.
+// This function converts them to a plain number.
+func parseSize(s string) (float64, error) {
+  s = strings.TrimSpace(s)
+  scale := 1.0
+  if n := len(s); n > 1 {
+    if i := strings.IndexByte("KMGTP", s[n-1]&^0x20); i >= 0 {
+      for ; i >= 0; i-- {
+        scale *= 1024
+      }
+      s = s[:n-1]
+    }
+  }
+  value, err := strconv.ParseFloat(s, 64)
+  if err != nil {
+    return 0, err
+  }
+  return value * scale, nil
+}